@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBatchReq - Bir nechta taskni bitta batch sifatida yaratish so'rovi
+type CreateBatchReq struct {
+	Name       string          `json:"name" binding:"required"`
+	Tasks      []CreateTaskReq `json:"tasks" binding:"required,dive"`
+	OnComplete *db.BatchHook   `json:"on_complete,omitempty"` // Barcha tasklar tugaganda bir marta bajariladi
+}
+
+// CreateBatch godoc
+// @Summary Create batch
+// @Description create many tasks in one transaction; on_complete (a task or a webhook) fires once when every task finished or was deleted. A webhook receives a "batch.completed" POST signed like task webhooks with hook_secret, which is returned only in this response; failed deliveries are retried and the result is shown as hook_delivery on GET /batches/{id}
+// @Tags batch
+// @Security ApiKeyAuth
+// @Param batch body CreateBatchReq true "Batch info"
+// @Success 200 {object} db.BatchStatus
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /batches [post]
+func (h *Handler) CreateBatch(c *gin.Context) {
+	h.Log.Info("CreateBatch is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CreateBatchReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	tasks := make([]db.Task, 0, len(req.Tasks))
+	for _, t := range req.Tasks {
+		tasks = append(tasks, t.toTask(userID))
+	}
+
+	batch, err := h.Task.CreateBatch(c, userID, req.Name, tasks, req.OnComplete)
+	if err != nil {
+		h.Log.Error("Create batch error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidBatch) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Batch yaratishda xato"})
+		return
+	}
+
+	h.Log.Info("Batch yaratildi", "id", batch.ID)
+	c.JSON(http.StatusOK, batch)
+}
+
+// GetBatch godoc
+// @Summary Get batch
+// @Description get batch with task counts by status. Tasks themselves are listed via GET /tasks?batch_id=
+// @Tags batch
+// @Security ApiKeyAuth
+// @Param id path string true "Batch ID"
+// @Success 200 {object} db.BatchStatus
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /batches/{id} [get]
+func (h *Handler) GetBatch(c *gin.Context) {
+	h.Log.Info("GetBatch is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	batch, err := h.Task.GetBatch(c, c.Param("id"))
+	if err != nil {
+		h.Log.Error("Get batch error: " + err.Error())
+		if errors.Is(err, storage.ErrBatchNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Batchni olishda xato"})
+		return
+	}
+
+	if role != string(db.RoleAdmin) && batch.CreatorID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Bu batchga ruxsat yo'q"})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
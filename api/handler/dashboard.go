@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"asynchronous/auth"
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// dashboardWriteTimeout - bitta xabarni yuborish uchun maksimal vaqt (sekin mijoz uchun)
+const dashboardWriteTimeout = 10 * time.Second
+
+// dashboardProtocol - brauzer JWT ni Sec-WebSocket-Protocol da yuboradi:
+// new WebSocket(url, ["bearer", token]). Server faqat "bearer" ni qaytaradi.
+// Token URL da yuborilmaydi, chunki so'rov manzili access logga yoziladi.
+const dashboardProtocol = "bearer"
+
+// Dashboard xabari turlari
+const (
+	dashboardSnapshot = "snapshot" // Navbatlar, workerlar va bajarilayotgan tasklar
+	dashboardTask     = "task"     // Bitta task holati o'zgardi
+)
+
+// DashboardMessage - WebSocket orqali yuboriladigan xabar
+type DashboardMessage struct {
+	Type     string                `json:"type"`
+	Snapshot *db.DashboardSnapshot `json:"snapshot,omitempty"`
+	Event    *db.TaskEvent         `json:"event,omitempty"`
+}
+
+// AdminDashboard godoc
+// @Summary Live worker dashboard
+// @Description WebSocket stream for admins: a snapshot of queue depth (with consumers, the number of live workers serving each queue; 0 means its tasks will not run), worker states and running tasks every few seconds, plus every task status transition. Browsers cannot set the Authorization header on WebSocket, so the JWT may instead be offered as a subprotocol: new WebSocket(url, ["bearer", token])
+// @Tags admin
+// @Param Authorization header string false "JWT"
+// @Param Sec-WebSocket-Protocol header string false "bearer, <JWT> (if Authorization cannot be set)"
+// @Success 101 {object} DashboardMessage
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Router /admin/dashboard/ws [get]
+func (h *Handler) AdminDashboard(c *gin.Context) {
+	h.Log.Info("AdminDashboard is starting")
+
+	token := c.GetHeader("Authorization")
+	subprotocol := false
+	if token == "" {
+		token, subprotocol = dashboardProtocolToken(c.GetHeader("Sec-WebSocket-Protocol"))
+	}
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Authorization is required"})
+		return
+	}
+
+	claims, err := auth.ExtractClaim(token)
+	if err != nil || claims == nil {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Invalid token provided"})
+		return
+	}
+	if role, _ := (*claims)["role"].(string); role != string(db.RoleAdmin) {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Dashboard faqat admin uchun"})
+		return
+	}
+	userID, _ := (*claims)["user_id"].(string)
+
+	// Origin tekshirilmaydi, chunki autentifikatsiya cookie emas, token orqali.
+	// Token subprotocol sifatida kelgan bo'lsa javobda faqat "bearer" tanlanadi.
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			config.Protocol = nil
+			if subprotocol {
+				config.Protocol = []string{dashboardProtocol}
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			h.dashboardLoop(ws, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// dashboardProtocolToken - "bearer, <JWT>" ko'rinishidagi Sec-WebSocket-Protocol dan token
+func dashboardProtocolToken(header string) (string, bool) {
+	parts := strings.Split(header, ",")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) != dashboardProtocol {
+		return "", false
+	}
+	token := strings.TrimSpace(parts[1])
+	return token, token != ""
+}
+
+// dashboardLoop - mijoz uzilguncha yoki server to'xtaguncha snapshot va hodisalarni yuborish
+func (h *Handler) dashboardLoop(ws *websocket.Conn, userID string) {
+	defer ws.Close()
+
+	events, unsubscribe := h.Events.SubscribeUser(userID, true)
+	defer unsubscribe()
+
+	// Mijozdan kelgan xabarlar kerak emas: o'qish faqat uzilishni aniqlash uchun
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		defer cancel()
+		var ignored string
+		for websocket.Message.Receive(ws, &ignored) == nil {
+		}
+	}()
+
+	h.Log.Info("Dashboard ulandi", "user_id", userID)
+	defer h.Log.Info("Dashboard uzildi", "user_id", userID)
+
+	ticker := time.NewTicker(service.StateReportInterval)
+	defer ticker.Stop()
+
+	for {
+		snapshot, err := h.Task.DashboardSnapshot(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			h.Log.Error("Dashboard snapshot error: " + err.Error())
+		} else if !h.sendDashboard(ws, DashboardMessage{Type: dashboardSnapshot, Snapshot: snapshot}) {
+			return
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					// Server to'xtatilmoqda
+					return
+				}
+				if !h.sendDashboard(ws, DashboardMessage{Type: dashboardTask, Event: &event}) {
+					return
+				}
+			case <-ticker.C:
+				break wait
+			}
+		}
+	}
+}
+
+func (h *Handler) sendDashboard(ws *websocket.Conn, msg DashboardMessage) bool {
+	if err := ws.SetWriteDeadline(time.Now().Add(dashboardWriteTimeout)); err != nil {
+		return false
+	}
+	if err := websocket.JSON.Send(ws, msg); err != nil {
+		h.Log.Warn("Dashboardga yuborib bo'lmadi", "error", err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"asynchronous/service"
+	"asynchronous/storage"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadLetterBulkReq - bir nechta dead-letter task ustida amal bajarish so'rovi.
+// IDs bo'sh bo'lsa, tasodifan hammasini o'zgartirib yubormaslik uchun all=true talab qilinadi.
+type DeadLetterBulkReq struct {
+	IDs  []string `json:"ids,omitempty"`
+	Type string   `json:"type,omitempty"` // Faqat shu turdagi tasklar
+	All  bool     `json:"all,omitempty"`
+}
+
+// RequeueResp - qayta navbatga qo'yilgan tasklar
+type RequeueResp struct {
+	Count   int      `json:"count"`
+	TaskIDs []string `json:"task_ids"`
+}
+
+// PurgeResp - o'chirilgan tasklar soni
+type PurgeResp struct {
+	Count int64 `json:"count"`
+}
+
+// bindDeadLetterFilter - bulk so'rovni filterga aylantirish.
+// Xato bo'lsa javob yozilgan bo'ladi va false qaytadi.
+func (h *Handler) bindDeadLetterFilter(c *gin.Context) (storage.DeadLetterFilter, bool) {
+	var req DeadLetterBulkReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return storage.DeadLetterFilter{}, false
+	}
+
+	if len(req.IDs) == 0 && !req.All {
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "ids yoki all=true talab qilinadi"})
+		return storage.DeadLetterFilter{}, false
+	}
+
+	return storage.DeadLetterFilter{IDs: req.IDs, Type: req.Type}, true
+}
+
+// ListDeadLetters godoc
+// @Summary List dead letters
+// @Description list tasks that exhausted their retries, newest first
+// @Tags dead-letter
+// @Security ApiKeyAuth
+// @Param type query string false "Task type"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} db.DeadLetter
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/dead-letters [get]
+func (h *Handler) ListDeadLetters(c *gin.Context) {
+	h.Log.Info("ListDeadLetters is starting")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	list, err := h.Task.ListDeadLetters(c, storage.DeadLetterFilter{Type: c.Query("type")}, limit, offset)
+	if err != nil {
+		h.Log.Error("List dead letters error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Dead-letter tasklarni olishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetDeadLetter godoc
+// @Summary Get dead letter
+// @Description get dead-lettered task with its full error history
+// @Tags dead-letter
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} db.DeadLetter
+// @Failure 401 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/dead-letters/{id} [get]
+func (h *Handler) GetDeadLetter(c *gin.Context) {
+	h.Log.Info("GetDeadLetter is starting")
+
+	dl, err := h.Task.GetDeadLetter(c, c.Param("id"))
+	if err != nil {
+		h.Log.Error("Get dead letter error: " + err.Error())
+		if errors.Is(err, storage.ErrDeadLetterNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Dead-letter taskni olishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, dl)
+}
+
+// RequeueDeadLetter godoc
+// @Summary Requeue dead letter
+// @Description put a single dead-lettered task back into the queue with retries reset
+// @Tags dead-letter
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} db.Task
+// @Failure 401 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 409 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/dead-letters/{id}/requeue [post]
+func (h *Handler) RequeueDeadLetter(c *gin.Context) {
+	h.Log.Info("RequeueDeadLetter is starting")
+
+	tasks, err := h.Task.RequeueDeadLetters(c, storage.DeadLetterFilter{IDs: []string{c.Param("id")}})
+	if err != nil {
+		h.Log.Error("Requeue dead letter error: " + err.Error())
+		if errors.Is(err, service.ErrDuplicateTask) {
+			c.JSON(http.StatusConflict, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Qayta navbatga qo'yishda xato"})
+		return
+	}
+	if len(tasks) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResp{Error: storage.ErrDeadLetterNotFound.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks[0])
+}
+
+// RequeueDeadLetters godoc
+// @Summary Bulk requeue dead letters
+// @Description put the selected (or all, optionally of one type) dead-lettered tasks back into the queue
+// @Tags dead-letter
+// @Security ApiKeyAuth
+// @Param filter body DeadLetterBulkReq true "Selection"
+// @Success 200 {object} RequeueResp
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 409 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/dead-letters/requeue [post]
+func (h *Handler) RequeueDeadLetters(c *gin.Context) {
+	h.Log.Info("RequeueDeadLetters is starting")
+
+	filter, ok := h.bindDeadLetterFilter(c)
+	if !ok {
+		return
+	}
+
+	tasks, err := h.Task.RequeueDeadLetters(c, filter)
+	if err != nil {
+		h.Log.Error("Requeue dead letters error: " + err.Error())
+		if errors.Is(err, service.ErrDuplicateTask) {
+			c.JSON(http.StatusConflict, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Qayta navbatga qo'yishda xato"})
+		return
+	}
+
+	resp := RequeueResp{Count: len(tasks), TaskIDs: make([]string, 0, len(tasks))}
+	for _, task := range tasks {
+		resp.TaskIDs = append(resp.TaskIDs, task.ID)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// PurgeDeadLetters godoc
+// @Summary Purge dead letters
+// @Description permanently delete the selected (or all, optionally of one type) dead-lettered tasks
+// @Tags dead-letter
+// @Security ApiKeyAuth
+// @Param filter body DeadLetterBulkReq true "Selection"
+// @Success 200 {object} PurgeResp
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/dead-letters/purge [post]
+func (h *Handler) PurgeDeadLetters(c *gin.Context) {
+	h.Log.Info("PurgeDeadLetters is starting")
+
+	filter, ok := h.bindDeadLetterFilter(c)
+	if !ok {
+		return
+	}
+
+	n, err := h.Task.PurgeDeadLetters(c, filter)
+	if err != nil {
+		h.Log.Error("Purge dead letters error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Dead-letter tasklarni o'chirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, PurgeResp{Count: n})
+}
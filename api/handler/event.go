@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseKeepAlive - proxylar jim ulanishni uzmasligi uchun izoh yuborish oralig'i
+const sseKeepAlive = 15 * time.Second
+
+// TaskEvents godoc
+// @Summary Stream task events
+// @Description stream status transitions of the task as Server-Sent Events. The first event is the current status; the stream ends when the task reaches a final status
+// @Tags task
+// @Security ApiKeyAuth
+// @Produce text/event-stream
+// @Param id path string true "Task ID"
+// @Success 200 {object} db.TaskEvent
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id}/events [get]
+func (h *Handler) TaskEvents(c *gin.Context) {
+	h.Log.Info("TaskEvents is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	// Avval obuna, keyin joriy holat: oraliqda kelgan hodisa yo'qolmaydi
+	events, unsubscribe := h.Events.SubscribeTask(c.Param("id"))
+	defer unsubscribe()
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+
+	current := service.NewTaskEvent(task)
+	h.streamEvents(c, &current, events, func(event db.TaskEvent) bool {
+		return service.IsFinalStatus(event.Status)
+	})
+}
+
+// UserTaskEvents godoc
+// @Summary Stream user task events
+// @Description stream status transitions of tasks created by or assigned to the current user as Server-Sent Events. Admins may pass all=true to receive events of every task
+// @Tags task
+// @Security ApiKeyAuth
+// @Produce text/event-stream
+// @Param all query bool false "All tasks (admin only)"
+// @Success 200 {object} db.TaskEvent
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Router /tasks/events [get]
+func (h *Handler) UserTaskEvents(c *gin.Context) {
+	h.Log.Info("UserTaskEvents is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	all := c.Query("all") == "true"
+	if all && role != string(db.RoleAdmin) {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Barcha tasklar hodisalarini faqat admin ko'ra oladi"})
+		return
+	}
+
+	events, unsubscribe := h.Events.SubscribeUser(userID, all)
+	defer unsubscribe()
+
+	h.streamEvents(c, nil, events, nil)
+}
+
+// streamEvents - hodisalarni SSE sifatida mijoz uzilguncha, server to'xtaguncha
+// yoki done true qaytarguncha yuborish. first bo'lsa u birinchi yuboriladi.
+func (h *Handler) streamEvents(c *gin.Context, first *db.TaskEvent, events <-chan db.TaskEvent, done func(db.TaskEvent) bool) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx javobni buferlamasligi uchun
+	c.Status(http.StatusOK)
+
+	send := func(event db.TaskEvent) bool {
+		c.SSEvent("status", event)
+		c.Writer.Flush()
+		return done != nil && done(event)
+	}
+
+	if first != nil {
+		if send(*first) {
+			return
+		}
+	} else {
+		// Headerlar darhol yuboriladi: mijoz ulanish o'rnatilganini biladi
+		c.Writer.Flush()
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// Server to'xtatilmoqda
+				return
+			}
+			if send(event) {
+				return
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
@@ -8,11 +8,14 @@ import (
 )
 
 type Handler struct {
-	User   *service.UserService
-	Task   *service.TaskService
-	Result *service.ResultService
-	Log    *slog.Logger
-	Casbin *casbin.Enforcer
+	User      *service.UserService
+	Task      *service.TaskService
+	Result    *service.ResultService
+	Recurring *service.RecurringService
+	Events    *service.TaskEventHub
+	Webhook   *service.WebhookService
+	Log       *slog.Logger
+	Casbin    *casbin.Enforcer
 }
 
 type ErrorResp struct {
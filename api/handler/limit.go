@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TaskTypeLimitReq - task turi cheklovi. 0 - cheklovsiz.
+type TaskTypeLimitReq struct {
+	MaxConcurrency int     `json:"max_concurrency"`
+	RatePerSecond  float64 `json:"rate_per_second"`
+	Burst          int     `json:"burst,omitempty"` // Bo'sh bo'lsa rate_per_second ga teng
+}
+
+// ListTaskTypeLimits godoc
+// @Summary List task type limits
+// @Description list per-type limits. running counts tasks in progress across all workers; max_concurrency is enforced cluster-wide, rate_per_second per worker process
+// @Tags task-limit
+// @Security ApiKeyAuth
+// @Success 200 {array} db.TaskTypeLimitStatus
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/task-limits [get]
+func (h *Handler) ListTaskTypeLimits(c *gin.Context) {
+	h.Log.Info("ListTaskTypeLimits is starting")
+
+	list, err := h.Task.ListTypeLimits(c)
+	if err != nil {
+		h.Log.Error("List task type limits error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Cheklovlarni olishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// SetTaskTypeLimit godoc
+// @Summary Set task type limit
+// @Description create or replace the concurrency and rate limit of a task type; applied without restart
+// @Tags task-limit
+// @Security ApiKeyAuth
+// @Param type path string true "Task type"
+// @Param limit body TaskTypeLimitReq true "Limit"
+// @Success 200 {object} db.TaskTypeLimit
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/task-limits/{type} [put]
+func (h *Handler) SetTaskTypeLimit(c *gin.Context) {
+	h.Log.Info("SetTaskTypeLimit is starting")
+
+	var req TaskTypeLimitReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	limit, err := h.Task.SetTypeLimit(c, db.TaskTypeLimit{
+		Type:           c.Param("type"),
+		MaxConcurrency: req.MaxConcurrency,
+		RatePerSecond:  req.RatePerSecond,
+		Burst:          req.Burst,
+	})
+	if err != nil {
+		h.Log.Error("Set task type limit error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Cheklovni saqlashda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, limit)
+}
+
+// DeleteTaskTypeLimit godoc
+// @Summary Delete task type limit
+// @Description remove the limit of a task type
+// @Tags task-limit
+// @Security ApiKeyAuth
+// @Param type path string true "Task type"
+// @Success 200 {object} SuccessResp
+// @Failure 401 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/task-limits/{type} [delete]
+func (h *Handler) DeleteTaskTypeLimit(c *gin.Context) {
+	h.Log.Info("DeleteTaskTypeLimit is starting")
+
+	if err := h.Task.DeleteTypeLimit(c, c.Param("type")); err != nil {
+		h.Log.Error("Delete task type limit error: " + err.Error())
+		if errors.Is(err, storage.ErrTaskTypeLimitNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Cheklovni o'chirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResp{Message: "Cheklov olib tashlandi"})
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"asynchronous/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ScaleWorkersReq - workerlar sonini o'zgartirish so'rovi
+type ScaleWorkersReq struct {
+	InstanceID string `json:"instance_id,omitempty"` // Bo'sh bo'lsa barcha ishlayotgan worker jarayonlari
+	Queue      string `json:"queue,omitempty"`       // Bo'sh bo'lsa og'irlik bo'yicha ishlovchi umumiy workerlar
+	Workers    *int   `json:"workers" binding:"required"`
+}
+
+// ScaleWorkersResp - worker jarayonlariga yuborilgan buyruq
+type ScaleWorkersResp struct {
+	CommandID  int64  `json:"command_id"`
+	InstanceID string `json:"instance_id,omitempty"`
+	Queue      string `json:"queue,omitempty"`
+	Workers    int    `json:"workers"`
+}
+
+// GetWorkerPool godoc
+// @Summary Get worker pool state
+// @Description report queues and every worker (idle, busy with which task, or stopping) of each running worker process, as last reported by the processes themselves
+// @Tags worker-pool
+// @Security ApiKeyAuth
+// @Success 200 {array} db.PoolState
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/workers [get]
+func (h *Handler) GetWorkerPool(c *gin.Context) {
+	h.Log.Info("GetWorkerPool is starting")
+
+	states, err := h.Task.PoolStates(c)
+	if err != nil {
+		h.Log.Error("Get worker pool error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Worker holatlarini olishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, states)
+}
+
+// ScaleWorkers godoc
+// @Summary Scale workers
+// @Description change the number of shared workers, or of the dedicated workers of a queue, in every running worker process (or only in instance_id). The command is delivered asynchronously; removed workers finish their current task first. Poll GET /admin/workers to see the result
+// @Tags worker-pool
+// @Security ApiKeyAuth
+// @Param scale body ScaleWorkersReq true "Target size"
+// @Success 202 {object} ScaleWorkersResp
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/workers/scale [put]
+func (h *Handler) ScaleWorkers(c *gin.Context) {
+	h.Log.Info("ScaleWorkers is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req ScaleWorkersReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	cmd, err := h.Task.ScaleWorkers(c, req.InstanceID, req.Queue, *req.Workers, userID)
+	if err != nil {
+		h.Log.Error("Scale workers error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidScale) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Workerlar sonini o'zgartirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, ScaleWorkersResp{
+		CommandID:  cmd.ID,
+		InstanceID: req.InstanceID,
+		Queue:      cmd.Queue,
+		Workers:    *cmd.Workers,
+	})
+}
+
+// PauseQueue godoc
+// @Summary Pause queue
+// @Description stop taking new tasks from the queue in every worker process; in-flight tasks finish
+// @Tags worker-pool
+// @Security ApiKeyAuth
+// @Param name path string true "Queue name"
+// @Success 200 {object} SuccessResp
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/queues/{name}/pause [post]
+func (h *Handler) PauseQueue(c *gin.Context) {
+	h.Log.Info("PauseQueue is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	if err := h.Task.PauseQueue(c, c.Param("name"), userID); err != nil {
+		h.Log.Error("Pause queue error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Navbatni to'xtatishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResp{Message: "Navbat to'xtatildi"})
+}
+
+// ResumeQueue godoc
+// @Summary Resume queue
+// @Description resume taking tasks from a paused queue
+// @Tags worker-pool
+// @Security ApiKeyAuth
+// @Param name path string true "Queue name"
+// @Success 200 {object} SuccessResp
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /admin/queues/{name}/resume [post]
+func (h *Handler) ResumeQueue(c *gin.Context) {
+	h.Log.Info("ResumeQueue is starting")
+
+	if err := h.Task.ResumeQueue(c, c.Param("name")); err != nil {
+		h.Log.Error("Resume queue error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidTask) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Navbatni davom ettirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResp{Message: "Navbat davom ettirildi"})
+}
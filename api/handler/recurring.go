@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateRecurringTaskReq - Takrorlanuvchi task yaratish so'rovi
+type CreateRecurringTaskReq struct {
+	Title          string          `json:"title" binding:"required"`
+	Type           string          `json:"type" binding:"required"`
+	CronExpr       string          `json:"cron_expr" binding:"required"`
+	Timezone       string          `json:"timezone,omitempty"` // Standart: UTC
+	UserID         string          `json:"user_id,omitempty"`
+	Priority       int             `json:"priority,omitempty"`
+	Queue          string          `json:"queue,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxRetries     int             `json:"max_retries,omitempty"`
+	RetryPolicy    *db.RetryPolicy `json:"retry_policy,omitempty"`
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"`
+}
+
+// recurringErrorStatus - service xatosiga mos HTTP status
+func recurringErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrRecurringTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidRecurringTask):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// loadRecurringTask - takrorlanuvchi taskni olish va huquqni tekshirish.
+// Xato bo'lsa javob yozilgan bo'ladi va nil qaytadi.
+func (h *Handler) loadRecurringTask(c *gin.Context, userID, role string) *db.RecurringTask {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "ID talab qilinadi"})
+		return nil
+	}
+
+	rt, err := h.Recurring.GetRecurringTask(c, id)
+	if err != nil {
+		h.Log.Error("Get recurring task error: " + err.Error())
+		c.JSON(recurringErrorStatus(err), ErrorResp{Error: "Takrorlanuvchi taskni olishda xato"})
+		return nil
+	}
+
+	if role != string(db.RoleAdmin) && rt.CreatorID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Bu takrorlanuvchi taskka ruxsat yo'q"})
+		return nil
+	}
+	return rt
+}
+
+// CreateRecurringTask godoc
+// @Summary Create recurring task
+// @Description create a cron schedule that creates a task at every fire time
+// @Tags recurring
+// @Security ApiKeyAuth
+// @Param schedule body CreateRecurringTaskReq true "Schedule info"
+// @Success 200 {object} db.RecurringTask
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /recurring-tasks [post]
+func (h *Handler) CreateRecurringTask(c *gin.Context) {
+	h.Log.Info("CreateRecurringTask is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CreateRecurringTaskReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	rt := db.RecurringTask{
+		CreatorID:      userID,
+		UserID:         req.UserID,
+		Title:          req.Title,
+		Type:           req.Type,
+		Priority:       req.Priority,
+		Queue:          req.Queue,
+		Payload:        req.Payload,
+		MaxRetries:     req.MaxRetries,
+		RetryPolicy:    req.RetryPolicy,
+		TimeoutSeconds: req.TimeoutSeconds,
+		CronExpr:       req.CronExpr,
+		Timezone:       req.Timezone,
+	}
+	if rt.UserID == "" {
+		rt.UserID = userID
+	}
+
+	created, err := h.Recurring.CreateRecurringTask(c, rt)
+	if err != nil {
+		h.Log.Error("Create recurring task error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidRecurringTask) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Takrorlanuvchi task yaratishda xato"})
+		return
+	}
+
+	h.Log.Info("Takrorlanuvchi task yaratildi", "id", created.ID)
+	c.JSON(http.StatusOK, created)
+}
+
+// ListRecurringTasks godoc
+// @Summary List recurring tasks
+// @Description list cron schedules. Non-admin users see only schedules they created
+// @Tags recurring
+// @Security ApiKeyAuth
+// @Param type query string false "Task type"
+// @Param paused query bool false "Paused"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} db.RecurringTask
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /recurring-tasks [get]
+func (h *Handler) ListRecurringTasks(c *gin.Context) {
+	h.Log.Info("ListRecurringTasks is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	filters := make(map[string]interface{})
+	if val := c.Query("type"); val != "" {
+		filters["type"] = val
+	}
+	if val := c.Query("paused"); val != "" {
+		paused, err := strconv.ParseBool(val)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: "paused true yoki false bo'lishi kerak"})
+			return
+		}
+		filters["paused"] = paused
+	}
+	if role != string(db.RoleAdmin) {
+		filters["creator_id"] = userID
+	}
+
+	list, err := h.Recurring.ListRecurringTasks(c, filters, limit, offset)
+	if err != nil {
+		h.Log.Error("List recurring tasks error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Takrorlanuvchi tasklarni olishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetRecurringTask godoc
+// @Summary Get recurring task
+// @Description get cron schedule by id
+// @Tags recurring
+// @Security ApiKeyAuth
+// @Param id path string true "Recurring task ID"
+// @Success 200 {object} db.RecurringTask
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /recurring-tasks/{id} [get]
+func (h *Handler) GetRecurringTask(c *gin.Context) {
+	h.Log.Info("GetRecurringTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	rt := h.loadRecurringTask(c, userID, role)
+	if rt == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, rt)
+}
+
+// PauseRecurringTask godoc
+// @Summary Pause recurring task
+// @Description stop creating tasks from the schedule until resumed
+// @Tags recurring
+// @Security ApiKeyAuth
+// @Param id path string true "Recurring task ID"
+// @Success 200 {object} db.RecurringTask
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /recurring-tasks/{id}/pause [post]
+func (h *Handler) PauseRecurringTask(c *gin.Context) {
+	h.Log.Info("PauseRecurringTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	rt := h.loadRecurringTask(c, userID, role)
+	if rt == nil {
+		return
+	}
+
+	paused, err := h.Recurring.PauseRecurringTask(c, rt.ID)
+	if err != nil {
+		h.Log.Error("Pause recurring task error: " + err.Error())
+		c.JSON(recurringErrorStatus(err), ErrorResp{Error: "To'xtatishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, paused)
+}
+
+// ResumeRecurringTask godoc
+// @Summary Resume recurring task
+// @Description resume a paused schedule; missed fire times are skipped
+// @Tags recurring
+// @Security ApiKeyAuth
+// @Param id path string true "Recurring task ID"
+// @Success 200 {object} db.RecurringTask
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /recurring-tasks/{id}/resume [post]
+func (h *Handler) ResumeRecurringTask(c *gin.Context) {
+	h.Log.Info("ResumeRecurringTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	rt := h.loadRecurringTask(c, userID, role)
+	if rt == nil {
+		return
+	}
+
+	resumed, err := h.Recurring.ResumeRecurringTask(c, rt.ID)
+	if err != nil {
+		h.Log.Error("Resume recurring task error: " + err.Error())
+		c.JSON(recurringErrorStatus(err), ErrorResp{Error: "Davom ettirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, resumed)
+}
@@ -0,0 +1,443 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateTaskReq - Task yaratish so'rovi
+type CreateTaskReq struct {
+	Title               string          `json:"title" binding:"required"`
+	Type                string          `json:"type" binding:"required"`
+	UserID              string          `json:"user_id,omitempty"` // Bo'sh bo'lsa task yaratuvchining o'ziga biriktiriladi
+	Priority            int             `json:"priority,omitempty"`
+	Queue               string          `json:"queue,omitempty"` // Bo'sh bo'lsa "default"
+	CanUserChangeStatus bool            `json:"can_user_change_status,omitempty"`
+	Payload             json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxRetries          int             `json:"max_retries,omitempty"`     // 100 dan oshmaydi
+	RetryPolicy         *db.RetryPolicy `json:"retry_policy,omitempty"`    // Bo'sh bo'lsa standart exponential siyosat
+	TimeoutSeconds      int             `json:"timeout_seconds,omitempty"` // Bo'sh bo'lsa WORKER_TASK_TIMEOUT
+	ScheduledAt         *time.Time      `json:"scheduled_at,omitempty"`
+	IdempotencyKey      string          `json:"idempotency_key,omitempty"` // Idempotency-Key headeri bilan bir xil
+	Unique              *UniqueReq      `json:"unique,omitempty"`          // Faqat POST /tasks da hisobga olinadi
+}
+
+// UniqueReq - xuddi shunday task pending/processing bo'lsa yangisini rad etish (reject)
+// yoki mavjudiga qo'shish (merge). Key bo'sh bo'lsa type + payload xeshi ishlatiladi.
+type UniqueReq struct {
+	Key        string `json:"key,omitempty"`
+	OnConflict string `json:"on_conflict,omitempty" enums:"reject,merge"`
+}
+
+// toTask - so'rovdan task yaratish; ijrochi ko'rsatilmasa task yaratuvchiga biriktiriladi
+func (req CreateTaskReq) toTask(userID string) db.Task {
+	task := db.Task{
+		CreatorID:           userID,
+		UserID:              req.UserID,
+		Title:               req.Title,
+		Type:                req.Type,
+		Priority:            req.Priority,
+		Queue:               req.Queue,
+		CanUserChangeStatus: req.CanUserChangeStatus,
+		Payload:             req.Payload,
+		MaxRetries:          req.MaxRetries,
+		RetryPolicy:         req.RetryPolicy,
+		TimeoutSeconds:      req.TimeoutSeconds,
+	}
+	if task.UserID == "" {
+		task.UserID = userID
+	}
+	if req.ScheduledAt != nil {
+		task.ScheduledAt = sql.NullTime{Time: *req.ScheduledAt, Valid: true}
+	}
+	return task
+}
+
+// currentUser - Check middleware qo'ygan foydalanuvchi ID va rolini olish
+func currentUser(c *gin.Context) (string, string, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return "", "", false
+	}
+	role, _ := c.Get("role")
+	roleStr, _ := role.(string)
+	return userID.(string), roleStr, true
+}
+
+// canAccessTask - admin, task yaratuvchisi yoki ijrochisi taskni ko'ra oladi
+func canAccessTask(task *db.Task, userID, role string) bool {
+	return role == string(db.RoleAdmin) || task.CreatorID == userID || task.UserID == userID
+}
+
+// taskErrorStatus - service xatosiga mos HTTP status
+func taskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidTask):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrInvalidTaskState), errors.Is(err, service.ErrDuplicateTask):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// loadTask - taskni olish va foydalanuvchining unga huquqini tekshirish.
+// Xato bo'lsa javob yozilgan bo'ladi va nil qaytadi.
+func (h *Handler) loadTask(c *gin.Context, userID, role string) *db.Task {
+	taskID := c.Param("id")
+	if taskID == "" {
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Task ID talab qilinadi"})
+		return nil
+	}
+
+	task, err := h.Task.GetTask(c, taskID)
+	if err != nil {
+		h.Log.Error("Get task error: " + err.Error())
+		c.JSON(taskErrorStatus(err), ErrorResp{Error: "Taskni olishda xato"})
+		return nil
+	}
+
+	if !canAccessTask(task, userID, role) {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Bu taskka ruxsat yo'q"})
+		return nil
+	}
+	return task
+}
+
+// CreateTask godoc
+// @Summary Create task
+// @Description create new task and put it into the queue
+// @Tags task
+// @Security ApiKeyAuth
+// @Param task body CreateTaskReq true "Task info"
+// @Param Idempotency-Key header string false "Repeated requests with the same key return the original task"
+// @Success 200 {object} db.Task
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 409 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks [post]
+func (h *Handler) CreateTask(c *gin.Context) {
+	h.Log.Info("CreateTask is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CreateTaskReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	task := req.toTask(userID)
+
+	// Kalit headerda yoki so'rov tanasida kelishi mumkin, lekin ikkalasi farq qilmasligi kerak
+	key := c.GetHeader("Idempotency-Key")
+	if key != "" && req.IdempotencyKey != "" && key != req.IdempotencyKey {
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Idempotency-Key headeri va idempotency_key mos emas"})
+		return
+	}
+	if key == "" {
+		key = req.IdempotencyKey
+	}
+	if key != "" {
+		task.IdempotencyKey = &key
+	}
+
+	var unique *service.UniqueOptions
+	if req.Unique != nil {
+		unique = &service.UniqueOptions{Key: req.Unique.Key, OnConflict: req.Unique.OnConflict}
+	}
+
+	created, isNew, err := h.Task.CreateTaskOnce(c, task, unique)
+	if err != nil {
+		h.Log.Error("Create task error: " + err.Error())
+		switch {
+		case errors.Is(err, service.ErrInvalidTask), errors.Is(err, service.ErrDuplicateTask):
+			c.JSON(taskErrorStatus(err), ErrorResp{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Task yaratishda xato"})
+		}
+		return
+	}
+
+	if !isNew {
+		if key != "" && created.IdempotencyKey != nil && *created.IdempotencyKey == key {
+			c.Header("Idempotent-Replayed", "true")
+		} else {
+			c.Header("Task-Merged", "true")
+		}
+	}
+
+	h.Log.Info("Task yaratildi", "task_id", created.ID, "replayed", !isNew)
+	c.JSON(http.StatusOK, created)
+}
+
+// GetTask godoc
+// @Summary Get task
+// @Description get task by id
+// @Tags task
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} db.Task
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id} [get]
+func (h *Handler) GetTask(c *gin.Context) {
+	h.Log.Info("GetTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// ListTaskAttempts godoc
+// @Summary List task attempts
+// @Description get every execution of the task with worker, timing, outcome and error
+// @Tags task
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {array} db.TaskAttempt
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id}/attempts [get]
+func (h *Handler) ListTaskAttempts(c *gin.Context) {
+	h.Log.Info("ListTaskAttempts is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+
+	attempts, err := h.Task.ListAttempts(c, task.ID)
+	if err != nil {
+		h.Log.Error("List task attempts error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Urinishlar tarixini olishda xato"})
+		return
+	}
+	if attempts == nil {
+		attempts = []db.TaskAttempt{}
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// ListTasks godoc
+// @Summary List tasks
+// @Description list tasks filtered by status, creator and assignee. Non-admin users see only their own tasks
+// @Tags task
+// @Security ApiKeyAuth
+// @Param status query string false "Status"
+// @Param creator_id query string false "Creator ID"
+// @Param user_id query string false "Assignee ID"
+// @Param batch_id query string false "Batch ID"
+// @Param queue query string false "Queue name"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} db.Task
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks [get]
+func (h *Handler) ListTasks(c *gin.Context) {
+	h.Log.Info("ListTasks is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	// Query parametrlarini olish
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	filters := make(map[string]interface{})
+	for _, key := range []string{"status", "creator_id", "user_id", "batch_id", "queue"} {
+		if val := c.Query(key); val != "" {
+			filters[key] = val
+		}
+	}
+
+	// Oddiy foydalanuvchi faqat o'zi yaratgan yoki o'ziga biriktirilgan tasklarni ko'radi
+	if role != string(db.RoleAdmin) && filters["creator_id"] != userID && filters["user_id"] != userID {
+		filters["user_id"] = userID
+	}
+
+	tasks, err := h.Task.ListTasks(c, filters, limit, offset)
+	if err != nil {
+		h.Log.Error("List tasks error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Tasklarni olishda xato"})
+		return
+	}
+
+	h.Log.Info("Tasklar ro'yxati olindi", "count", len(tasks))
+	c.JSON(http.StatusOK, tasks)
+}
+
+// DeleteTask godoc
+// @Summary Delete task
+// @Description soft delete task; a pending or running task is cancelled first (its handler is stopped and its dependants are skipped)
+// @Tags task
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} SuccessResp
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id} [delete]
+func (h *Handler) DeleteTask(c *gin.Context) {
+	h.Log.Info("DeleteTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+	if role != string(db.RoleAdmin) && task.CreatorID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Taskni faqat yaratuvchisi o'chira oladi"})
+		return
+	}
+
+	if err := h.Task.DeleteTask(c, task.ID, userID); err != nil {
+		h.Log.Error("Delete task error: " + err.Error())
+		c.JSON(taskErrorStatus(err), ErrorResp{Error: "Taskni o'chirishda xato"})
+		return
+	}
+
+	h.Log.Info("Task o'chirildi", "task_id", task.ID)
+	c.JSON(http.StatusOK, SuccessResp{Message: "Task muvaffaqiyatli o'chirildi"})
+}
+
+// CancelTaskReq - Taskni bekor qilish so'rovi
+type CancelTaskReq struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// CancelTask godoc
+// @Summary Cancel task
+// @Description cancel a pending or running task; a running handler is signalled through its context
+// @Tags task
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Param cancel body CancelTaskReq false "Cancel reason"
+// @Success 200 {object} db.Task
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 409 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id}/cancel [post]
+func (h *Handler) CancelTask(c *gin.Context) {
+	h.Log.Info("CancelTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CancelTaskReq
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			h.Log.Error("Binding error: " + err.Error())
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+			return
+		}
+	}
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+	if role != string(db.RoleAdmin) && task.CreatorID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Taskni faqat yaratuvchisi bekor qila oladi"})
+		return
+	}
+
+	cancelled, err := h.Task.CancelTask(c, task.ID, userID, req.Reason)
+	if err != nil {
+		h.Log.Error("Cancel task error: " + err.Error())
+		c.JSON(taskErrorStatus(err), ErrorResp{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cancelled)
+}
+
+// RetryTask godoc
+// @Summary Retry task
+// @Description put a failed task back into the queue
+// @Tags task
+// @Security ApiKeyAuth
+// @Param id path string true "Task ID"
+// @Success 200 {object} db.Task
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 409 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /tasks/{id}/retry [post]
+func (h *Handler) RetryTask(c *gin.Context) {
+	h.Log.Info("RetryTask is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	task := h.loadTask(c, userID, role)
+	if task == nil {
+		return
+	}
+
+	retried, err := h.Task.RetryTask(c, task.ID)
+	if err != nil {
+		h.Log.Error("Retry task error: " + err.Error())
+		c.JSON(taskErrorStatus(err), ErrorResp{Error: err.Error()})
+		return
+	}
+
+	h.Log.Info("Task qayta navbatga qo'yildi", "task_id", task.ID)
+	c.JSON(http.StatusOK, retried)
+}
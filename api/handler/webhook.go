@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhookReq - webhook yaratish so'rovi
+type CreateWebhookReq struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret,omitempty"` // Bo'sh bo'lsa yaratiladi va javobda bir marta qaytariladi
+	Events []string `json:"events,omitempty"` // Masalan: ["task.completed", "task.failed"]; bo'sh - barchasi
+}
+
+// webhookErrorStatus - service xatosiga mos HTTP status
+func webhookErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrWebhookNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidWebhook):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// loadWebhook - webhookni olish va huquqni tekshirish.
+// Xato bo'lsa javob yozilgan bo'ladi va nil qaytadi.
+func (h *Handler) loadWebhook(c *gin.Context, userID, role string) *db.Webhook {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "ID talab qilinadi"})
+		return nil
+	}
+
+	w, err := h.Webhook.GetWebhook(c, id)
+	if err != nil {
+		h.Log.Error("Get webhook error: " + err.Error())
+		c.JSON(webhookErrorStatus(err), ErrorResp{Error: "Webhookni olishda xato"})
+		return nil
+	}
+
+	if role != string(db.RoleAdmin) && w.UserID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Bu webhookka ruxsat yo'q"})
+		return nil
+	}
+	return w
+}
+
+// CreateWebhook godoc
+// @Summary Create webhook
+// @Description subscribe a URL to lifecycle events of tasks you created or are assigned to. Requests are signed: X-Webhook-Signature is "sha256=" + hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" with the secret. The secret is returned only in this response. URLs resolving to loopback, private or link-local addresses are rejected
+// @Tags webhook
+// @Security ApiKeyAuth
+// @Param webhook body CreateWebhookReq true "Webhook info"
+// @Success 200 {object} db.Webhook
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /webhooks [post]
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	h.Log.Info("CreateWebhook is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CreateWebhookReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	created, err := h.Webhook.CreateWebhook(c, db.Webhook{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		h.Log.Error("Create webhook error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidWebhook) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Webhook yaratishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, created)
+}
+
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description list your webhooks. Admins may pass all=true to list every user's webhooks
+// @Tags webhook
+// @Security ApiKeyAuth
+// @Param all query bool false "All users (admin only)"
+// @Success 200 {array} db.Webhook
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /webhooks [get]
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	h.Log.Info("ListWebhooks is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	owner := userID
+	if c.Query("all") == "true" {
+		if role != string(db.RoleAdmin) {
+			c.JSON(http.StatusForbidden, ErrorResp{Error: "Barcha webhooklarni faqat admin ko'ra oladi"})
+			return
+		}
+		owner = ""
+	}
+
+	list, err := h.Webhook.ListWebhooks(c, owner)
+	if err != nil {
+		h.Log.Error("List webhooks error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Webhooklarni olishda xato"})
+		return
+	}
+	if list == nil {
+		list = []db.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetWebhook godoc
+// @Summary Get webhook
+// @Description get webhook by id (without the secret)
+// @Tags webhook
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} db.Webhook
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /webhooks/{id} [get]
+func (h *Handler) GetWebhook(c *gin.Context) {
+	h.Log.Info("GetWebhook is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	w := h.loadWebhook(c, userID, role)
+	if w == nil {
+		return
+	}
+
+	c.JSON(http.StatusOK, w)
+}
+
+// DeleteWebhook godoc
+// @Summary Delete webhook
+// @Description delete webhook together with its delivery log
+// @Tags webhook
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} SuccessResp
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /webhooks/{id} [delete]
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	h.Log.Info("DeleteWebhook is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	w := h.loadWebhook(c, userID, role)
+	if w == nil {
+		return
+	}
+
+	if err := h.Webhook.DeleteWebhook(c, w.ID); err != nil {
+		h.Log.Error("Delete webhook error: " + err.Error())
+		c.JSON(webhookErrorStatus(err), ErrorResp{Error: "Webhookni o'chirishda xato"})
+		return
+	}
+
+	c.JSON(http.StatusOK, SuccessResp{Message: "Webhook o'chirildi"})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary List webhook deliveries
+// @Description delivery log of the webhook with attempts, last response code and error
+// @Tags webhook
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Limit"
+// @Param offset query int false "Offset"
+// @Success 200 {array} db.WebhookDelivery
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /webhooks/{id}/deliveries [get]
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	h.Log.Info("ListWebhookDeliveries is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	w := h.loadWebhook(c, userID, role)
+	if w == nil {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	list, err := h.Webhook.ListDeliveries(c, w.ID, limit, offset)
+	if err != nil {
+		h.Log.Error("List webhook deliveries error: " + err.Error())
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Webhook yuborishlarini olishda xato"})
+		return
+	}
+	if list == nil {
+		list = []db.WebhookDelivery{}
+	}
+
+	c.JSON(http.StatusOK, list)
+}
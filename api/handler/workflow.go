@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/service"
+	"asynchronous/storage"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowTaskReq - workflowdagi bitta task
+type WorkflowTaskReq struct {
+	Key            string          `json:"key" binding:"required"` // So'rov ichidagi nom: depends_on shu kalitlarga ishora qiladi
+	Title          string          `json:"title" binding:"required"`
+	Type           string          `json:"type" binding:"required"`
+	UserID         string          `json:"user_id,omitempty"`
+	Priority       int             `json:"priority,omitempty"`
+	Queue          string          `json:"queue,omitempty"`
+	Payload        json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxRetries     int             `json:"max_retries,omitempty"`
+	RetryPolicy    *db.RetryPolicy `json:"retry_policy,omitempty"`
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"`
+	DependsOn      []string        `json:"depends_on,omitempty"`
+}
+
+// CreateWorkflowReq - Workflow (tasklar DAG i) yaratish so'rovi
+type CreateWorkflowReq struct {
+	Name  string            `json:"name" binding:"required"`
+	Tasks []WorkflowTaskReq `json:"tasks" binding:"required,dive"`
+}
+
+// CreateWorkflow godoc
+// @Summary Create workflow
+// @Description submit a DAG of tasks; a task runs only after all tasks it depends on completed, and is skipped if any of them fails
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param workflow body CreateWorkflowReq true "Workflow info"
+// @Success 200 {object} db.WorkflowStatus
+// @Failure 400 {object} ErrorResp
+// @Failure 401 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /workflows [post]
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	h.Log.Info("CreateWorkflow is starting")
+
+	userID, _, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	var req CreateWorkflowReq
+	if err := c.BindJSON(&req); err != nil {
+		h.Log.Error("Binding error: " + err.Error())
+		c.JSON(http.StatusBadRequest, ErrorResp{Error: "Noto'g'ri so'rov formati"})
+		return
+	}
+
+	items := make([]service.WorkflowTask, 0, len(req.Tasks))
+	for _, t := range req.Tasks {
+		task := db.Task{
+			UserID:         t.UserID,
+			Title:          t.Title,
+			Type:           t.Type,
+			Priority:       t.Priority,
+			Queue:          t.Queue,
+			Payload:        t.Payload,
+			MaxRetries:     t.MaxRetries,
+			RetryPolicy:    t.RetryPolicy,
+			TimeoutSeconds: t.TimeoutSeconds,
+		}
+		if task.UserID == "" {
+			task.UserID = userID
+		}
+		items = append(items, service.WorkflowTask{Key: t.Key, Task: task, DependsOn: t.DependsOn})
+	}
+
+	wf, err := h.Task.CreateWorkflow(c, userID, req.Name, items)
+	if err != nil {
+		h.Log.Error("Create workflow error: " + err.Error())
+		if errors.Is(err, service.ErrInvalidWorkflow) {
+			c.JSON(http.StatusBadRequest, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Workflow yaratishda xato"})
+		return
+	}
+
+	h.Log.Info("Workflow yaratildi", "id", wf.ID)
+	c.JSON(http.StatusOK, wf)
+}
+
+// GetWorkflow godoc
+// @Summary Get workflow
+// @Description get workflow with its tasks, dependencies, counts by status and aggregate status (running, completed, failed)
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} db.WorkflowStatus
+// @Failure 401 {object} ErrorResp
+// @Failure 403 {object} ErrorResp
+// @Failure 404 {object} ErrorResp
+// @Failure 500 {object} ErrorResp
+// @Router /workflows/{id} [get]
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	h.Log.Info("GetWorkflow is starting")
+
+	userID, role, ok := currentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, ErrorResp{Error: "Foydalanuvchi autentifikatsiyadan o'tmagan"})
+		return
+	}
+
+	wf, err := h.Task.GetWorkflow(c, c.Param("id"))
+	if err != nil {
+		h.Log.Error("Get workflow error: " + err.Error())
+		if errors.Is(err, storage.ErrWorkflowNotFound) {
+			c.JSON(http.StatusNotFound, ErrorResp{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, ErrorResp{Error: "Workflowni olishda xato"})
+		return
+	}
+
+	if role != string(db.RoleAdmin) && wf.CreatorID != userID {
+		c.JSON(http.StatusForbidden, ErrorResp{Error: "Bu workflowga ruxsat yo'q"})
+		return
+	}
+
+	c.JSON(http.StatusOK, wf)
+}
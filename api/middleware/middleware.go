@@ -40,6 +40,18 @@ func Check(c *gin.Context) {
 		})
 		return
 	}
+
+	// Handlerlar uchun foydalanuvchi ma'lumotlari
+	userID, role, err := auth.GetUserIdFromToken(refresh)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid token provided",
+		})
+		return
+	}
+	c.Set("userID", userID)
+	c.Set("role", role)
+
 	c.Next()
 }
 
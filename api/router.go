@@ -3,6 +3,7 @@ package api
 import (
 	// _ "asynchronous/api/docs"
 	"asynchronous/api/handler"
+	"asynchronous/api/middleware"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -19,5 +20,71 @@ import (
 func Router(hand *handler.Handler) *gin.Engine {
 	router := gin.Default()
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	casb := middleware.NewCasbinPermission(hand.Casbin)
+
+	auth := router.Group("/auth")
+	auth.POST("/register", hand.Register)
+	auth.POST("/login", hand.Login)
+
+	user := router.Group("/user", middleware.Check, casb.CheckPermissionMiddleware())
+	user.GET("/profile", hand.GetUserProfile)
+	user.PUT("", hand.UpdateUser)
+	user.PUT("/password", hand.UpdatePassword)
+
+	admin := router.Group("/admin", middleware.Check, casb.CheckPermissionMiddleware())
+	admin.GET("/users", hand.ListUsers)
+	admin.PUT("/users/:id/role", hand.UpdateUserRole)
+	admin.DELETE("/users/:id", hand.DeleteUser)
+	admin.GET("/dead-letters", hand.ListDeadLetters)
+	admin.GET("/dead-letters/:id", hand.GetDeadLetter)
+	admin.POST("/dead-letters/:id/requeue", hand.RequeueDeadLetter)
+	admin.POST("/dead-letters/requeue", hand.RequeueDeadLetters)
+	admin.POST("/dead-letters/purge", hand.PurgeDeadLetters)
+	admin.GET("/task-limits", hand.ListTaskTypeLimits)
+	admin.PUT("/task-limits/:type", hand.SetTaskTypeLimit)
+	admin.DELETE("/task-limits/:type", hand.DeleteTaskTypeLimit)
+	admin.GET("/workers", hand.GetWorkerPool)
+	admin.PUT("/workers/scale", hand.ScaleWorkers)
+	admin.POST("/queues/:name/pause", hand.PauseQueue)
+	admin.POST("/queues/:name/resume", hand.ResumeQueue)
+
+	// WebSocket: brauzer Authorization header qo'ya olmaydi, token Sec-WebSocket-Protocol
+	// orqali kelishi mumkin, shuning uchun u handlerning o'zida tekshiriladi
+	router.GET("/admin/dashboard/ws", hand.AdminDashboard)
+
+	tasks := router.Group("/tasks", middleware.Check, casb.CheckPermissionMiddleware())
+	tasks.POST("", hand.CreateTask)
+	tasks.GET("", hand.ListTasks)
+	tasks.GET("/events", hand.UserTaskEvents)
+	tasks.GET("/:id", hand.GetTask)
+	tasks.GET("/:id/attempts", hand.ListTaskAttempts)
+	tasks.GET("/:id/events", hand.TaskEvents)
+	tasks.DELETE("/:id", hand.DeleteTask)
+	tasks.POST("/:id/retry", hand.RetryTask)
+	tasks.POST("/:id/cancel", hand.CancelTask)
+
+	recurring := router.Group("/recurring-tasks", middleware.Check, casb.CheckPermissionMiddleware())
+	recurring.POST("", hand.CreateRecurringTask)
+	recurring.GET("", hand.ListRecurringTasks)
+	recurring.GET("/:id", hand.GetRecurringTask)
+	recurring.POST("/:id/pause", hand.PauseRecurringTask)
+	recurring.POST("/:id/resume", hand.ResumeRecurringTask)
+
+	workflows := router.Group("/workflows", middleware.Check, casb.CheckPermissionMiddleware())
+	workflows.POST("", hand.CreateWorkflow)
+	workflows.GET("/:id", hand.GetWorkflow)
+
+	batches := router.Group("/batches", middleware.Check, casb.CheckPermissionMiddleware())
+	batches.POST("", hand.CreateBatch)
+	batches.GET("/:id", hand.GetBatch)
+
+	webhooks := router.Group("/webhooks", middleware.Check, casb.CheckPermissionMiddleware())
+	webhooks.POST("", hand.CreateWebhook)
+	webhooks.GET("", hand.ListWebhooks)
+	webhooks.GET("/:id", hand.GetWebhook)
+	webhooks.DELETE("/:id", hand.DeleteWebhook)
+	webhooks.GET("/:id/deliveries", hand.ListWebhookDeliveries)
+
 	return router
 }
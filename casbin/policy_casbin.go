@@ -51,7 +51,22 @@ func CasbinEnforcer(logger *slog.Logger) (*casbin.Enforcer, error) {
 	}
 
 	policies := [][]string{
-		{"consumer", "/cards", "POST"},
+		// admin
+		{"admin", "/user*", "GET|PUT"},
+		{"admin", "/admin/*", "GET|POST|PUT|DELETE"},
+		{"admin", "/tasks*", "GET|POST|DELETE"},
+		{"admin", "/recurring-tasks*", "GET|POST"},
+		{"admin", "/workflows*", "GET|POST"},
+		{"admin", "/batches*", "GET|POST"},
+		{"admin", "/webhooks*", "GET|POST|DELETE"},
+
+		// worker
+		{"worker", "/user*", "GET|PUT"},
+		{"worker", "/tasks*", "GET|POST|DELETE"},
+		{"worker", "/recurring-tasks*", "GET|POST"},
+		{"worker", "/workflows*", "GET|POST"},
+		{"worker", "/batches*", "GET|POST"},
+		{"worker", "/webhooks*", "GET|POST|DELETE"},
 	}
 
 	_, err = enforcer.AddPolicies(policies)
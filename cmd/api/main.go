@@ -0,0 +1,117 @@
+// cmd/api - faqat HTTP so'rovlarni qabul qiladi: tasklar bazaga yoziladi va ularni
+// cmd/worker jarayonlari bajaradi. Ikkalasi alohida masshtablanadi.
+package main
+
+import (
+	"asynchronous/api"
+	"asynchronous/api/handler"
+	"asynchronous/casbin"
+	"asynchronous/config"
+	"asynchronous/logs"
+	"asynchronous/service"
+	"asynchronous/storage/postgres"
+	"context"
+	"errors"
+	pc "github.com/casbin/casbin/v2"
+	"log"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	cfg := config.Load()
+
+	logger := logs.NewLogger()
+
+	db, err := postgres.ConnectionDb()
+	if err != nil {
+		log.Fatal("Databasega ulanishda xato: ", err)
+	}
+
+	casbin, err := casbin.CasbinEnforcer(logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	strg := postgres.NewPostgresStorage(db)
+
+	defer strg.Close()
+
+	userService := service.NewUserService(strg, logger)
+	taskQueue, err := service.NewTaskQueue(cfg.Worker, strg, logger)
+	if err != nil {
+		log.Fatal("Navbatni ishga tushirishda xato: ", err)
+	}
+
+	taskService := service.NewTaskService(strg, logger, cfg.Worker, taskQueue)
+	resultService := service.NewResultService(db, logger)
+	recurringService := service.NewRecurringService(strg, logger, taskService, cfg.Worker.RecurringInterval)
+	webhookService := service.NewWebhookService(strg, logger, cfg.Webhook)
+
+	// Task hodisalari barcha instancelardan LISTEN/NOTIFY orqali keladi
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+	events, err := postgres.ListenTaskEvents(eventsCtx, logger)
+	if err != nil {
+		log.Fatal("Task hodisalarini tinglashda xato: ", err)
+	}
+	eventHub := service.NewTaskEventHub(logger)
+	eventHub.Start(events)
+
+	hand := NewHandler(userService, taskService, resultService, recurringService, eventHub, webhookService, logger, casbin)
+	router := api.Router(hand)
+
+	srv := &http.Server{
+		Addr:    cfg.Server.ROUTER,
+		Handler: router,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	// SIGINT/SIGTERM kelguncha kutish
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	logger.Info("To'xtatish signali olindi", "timeout", cfg.Worker.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Worker.ShutdownTimeout)
+	defer cancel()
+
+	// SSE ulanishlari o'z-o'zidan tugamaydi: Shutdown ularni kutib qolmasligi uchun avval yopiladi
+	stopEvents()
+
+	// Yangi HTTP so'rovlarni qabul qilmaslik, boshlanganlarini tugatish
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logger.Error("HTTP serverni to'xtatishda xato", "error", err)
+	}
+
+	logger.Info("Server to'xtadi")
+}
+
+func NewHandler(
+	userService *service.UserService,
+	taskService *service.TaskService,
+	resultService *service.ResultService,
+	recurringService *service.RecurringService,
+	eventHub *service.TaskEventHub,
+	webhookService *service.WebhookService,
+	logger *slog.Logger,
+	casbin *pc.Enforcer,
+) *handler.Handler {
+	return &handler.Handler{
+		User:      userService,
+		Task:      taskService,
+		Result:    resultService,
+		Recurring: recurringService,
+		Events:    eventHub,
+		Webhook:   webhookService,
+		Log:       logger,
+		Casbin:    casbin,
+	}
+}
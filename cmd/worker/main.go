@@ -0,0 +1,75 @@
+// cmd/worker - faqat tasklarni bajaradi: umumiy navbatdan (Postgres yoki Redis) task oladi,
+// takrorlanuvchi tasklarni yaratadi, lease muddati o'tganlarini qaytaradi va webhooklarni yuboradi.
+// HTTP so'rovlarni cmd/api qabul qiladi; ikkalasi config.Load dan foydalanadi.
+package main
+
+import (
+	"asynchronous/config"
+	"asynchronous/logs"
+	"asynchronous/service"
+	"asynchronous/storage/postgres"
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+)
+
+func main() {
+	cfg := config.Load()
+
+	logger := logs.NewLogger()
+
+	db, err := postgres.ConnectionDb()
+	if err != nil {
+		log.Fatal("Databasega ulanishda xato: ", err)
+	}
+
+	strg := postgres.NewPostgresStorage(db)
+
+	defer strg.Close()
+
+	taskQueue, err := service.NewTaskQueue(cfg.Worker, strg, logger)
+	if err != nil {
+		log.Fatal("Navbatni ishga tushirishda xato: ", err)
+	}
+
+	taskService := service.NewTaskService(strg, logger, cfg.Worker, taskQueue)
+	recurringService := service.NewRecurringService(strg, logger, taskService, cfg.Worker.RecurringInterval)
+	webhookService := service.NewWebhookService(strg, logger, cfg.Webhook)
+
+	// Task turlari handlerlari shu yerda, StartWorkers dan oldin ro'yxatdan o'tkaziladi:
+	// taskService.RegisterHandler("email", emailHandler)
+
+	// Admin buyruqlari (workerlar soni, navbatni to'xtatish) API dan NOTIFY orqali keladi
+	commandsCtx, stopCommands := context.WithCancel(context.Background())
+	defer stopCommands()
+	commands, err := postgres.ListenWorkerCommands(commandsCtx, logger)
+	if err != nil {
+		log.Fatal("Worker buyruqlarini tinglashda xato: ", err)
+	}
+	taskService.WatchCommands(commands)
+
+	taskService.StartWorkers()
+	recurringService.Start()
+	webhookService.Start()
+	logger.Info("Worker ishga tushdi", "worker_count", cfg.Worker.WorkerCount, "queues", cfg.Worker.Queues)
+
+	// SIGINT/SIGTERM kelguncha kutish
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	logger.Info("To'xtatish signali olindi", "timeout", cfg.Worker.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Worker.ShutdownTimeout)
+	defer cancel()
+
+	// Yangi tasklarni yaratmaslik va workerlarga bermaslik, bajarilayotganlarini kutish
+	recurringService.Stop()
+	if err := taskService.StopWorkers(shutdownCtx); err != nil {
+		logger.Error("Workerlarni to'xtatishda xato", "error", err)
+	}
+	// Yuborilmagan webhooklar bazada qoladi va keyingi ishga tushishda yuboriladi
+	webhookService.Stop()
+
+	logger.Info("Worker to'xtadi")
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -16,10 +18,51 @@ type Config struct {
 	Redis    RedisConfig
 	Minio    MinioConfig
 	Email    EmailConfig
+	Webhook  WebhookConfig
 }
 
 type WorkerConfig struct {
-	WorkerCount int
+	WorkerCount   int           // Queues dagi og'irliklar bo'yicha barcha navbatlarga xizmat qiluvchi workerlar
+	Queues        []QueueConfig // Shu jarayon xizmat qiladigan navbatlar
+	PollInterval  time.Duration // Navbat bo'sh bo'lganda bazani qayta tekshirish oralig'i
+	PriorityAging time.Duration // Shu vaqt kutgan task priority si bittaga oshadi (0 - o'chirilgan)
+	// Scheduler kechiktirilgan tasklarni bazadan qayta yuklash oralig'i
+	ScheduleReload time.Duration
+	// Takrorlanuvchi (cron) tasklarning vaqti kelganini tekshirish oralig'i
+	RecurringInterval time.Duration
+	// To'xtatishda bajarilayotgan tasklarni kutishning maksimal vaqti
+	ShutdownTimeout time.Duration
+	// Band qilingan task leasi: worker shu vaqt ichida heartbeat yubormasa task qaytariladi
+	Lease time.Duration
+	// Muddati o'tgan leaselarni tekshirish oralig'i
+	ReaperInterval time.Duration
+	// Task o'z timeouti ko'rsatilmagan bo'lsa bajarilishi mumkin bo'lgan maksimal vaqt
+	TaskTimeout time.Duration
+	// Idempotency kaliti shu vaqt ichida takroriy task yaratilishiga yo'l qo'ymaydi
+	IdempotencyRetention time.Duration
+	// Task turlari cheklovlari va to'xtatilgan navbatlarni bazadan qayta yuklash oralig'i
+	LimitsReload time.Duration
+	// Tayyor tasklar workerlarga qanday yetkaziladi: "postgres" (tasks jadvali) yoki "redis" (Redis Streams)
+	QueueBackend string
+	// Redis backendida xabari yo'qolgan tayyor tasklarni bazadan qidirib qayta yuborish oralig'i
+	QueueResync time.Duration
+}
+
+// QueueConfig - nomlangan navbat sozlamasi
+type QueueConfig struct {
+	Name    string
+	Workers int // Faqat shu navbatga xizmat qiluvchi alohida workerlar
+	Weight  int // Umumiy workerlar shu navbatni boshqalarga nisbatan qanchalik tez-tez tanlashi (0 - tanlamaydi)
+}
+
+// WebhookConfig - task hodisalarini foydalanuvchi webhooklariga yuborish
+type WebhookConfig struct {
+	PollInterval time.Duration // Vaqti kelgan yuborishlarni tekshirish oralig'i
+	Timeout      time.Duration // Bitta so'rovning maksimal vaqti
+	MaxAttempts  int           // Shuncha urinishdan keyin yuborish "failed" bo'ladi
+	// Ichki tarmoq (loopback, private, link-local) manzillariga yuborishga ruxsat.
+	// Faqat lokal ishlab chiqish uchun: aks holda webhook orqali ichki servislarga so'rov yuborish mumkin.
+	AllowPrivate bool
 }
 
 type PostgresConfig struct {
@@ -90,12 +133,58 @@ func Load() *Config {
 			SENDER_EMAIL: cast.ToString(coalesce("SENDER_EMAIL", "your_email@example.com")),
 			APP_PASSWORD: cast.ToString(coalesce("APP_PASSWORD", "your_password")),
 		},
+		Webhook: WebhookConfig{
+			PollInterval: cast.ToDuration(coalesce("WEBHOOK_POLL_INTERVAL", 2*time.Second)),
+			Timeout:      cast.ToDuration(coalesce("WEBHOOK_TIMEOUT", 10*time.Second)),
+			MaxAttempts:  cast.ToInt(coalesce("WEBHOOK_MAX_ATTEMPTS", 8)),
+			AllowPrivate: cast.ToBool(coalesce("WEBHOOK_ALLOW_PRIVATE", false)),
+		},
 		Worker: WorkerConfig{
-			WorkerCount: cast.ToInt(coalesce("WORKER_COUNT", 10)),
+			WorkerCount:          cast.ToInt(coalesce("WORKER_COUNT", 10)),
+			Queues:               parseQueues(cast.ToString(coalesce("WORKER_QUEUES", "default:0:1"))),
+			PollInterval:         cast.ToDuration(coalesce("WORKER_POLL_INTERVAL", 2*time.Second)),
+			PriorityAging:        cast.ToDuration(coalesce("WORKER_PRIORITY_AGING", time.Minute)),
+			ScheduleReload:       cast.ToDuration(coalesce("SCHEDULER_RELOAD_INTERVAL", 30*time.Second)),
+			RecurringInterval:    cast.ToDuration(coalesce("RECURRING_CHECK_INTERVAL", 15*time.Second)),
+			ShutdownTimeout:      cast.ToDuration(coalesce("WORKER_SHUTDOWN_TIMEOUT", 30*time.Second)),
+			Lease:                cast.ToDuration(coalesce("WORKER_LEASE", time.Minute)),
+			ReaperInterval:       cast.ToDuration(coalesce("REAPER_INTERVAL", 30*time.Second)),
+			TaskTimeout:          cast.ToDuration(coalesce("WORKER_TASK_TIMEOUT", 10*time.Minute)),
+			IdempotencyRetention: cast.ToDuration(coalesce("IDEMPOTENCY_RETENTION", 24*time.Hour)),
+			LimitsReload:         cast.ToDuration(coalesce("WORKER_LIMITS_RELOAD", 30*time.Second)),
+			QueueBackend:         cast.ToString(coalesce("WORKER_QUEUE_BACKEND", "postgres")),
+			QueueResync:          cast.ToDuration(coalesce("WORKER_QUEUE_RESYNC", 5*time.Minute)),
 		},
 	}
 }
 
+// parseQueues - "nom:workerlar:og'irlik" ro'yxatini o'qish, masalan:
+// "critical:2:6,default:0:3,bulk:1:1". Workerlar va og'irlik ko'rsatilmasa 0 va 1.
+func parseQueues(spec string) []QueueConfig {
+	var queues []QueueConfig
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := strings.Split(item, ":")
+		q := QueueConfig{Name: strings.TrimSpace(parts[0]), Weight: 1}
+		if len(parts) > 1 {
+			q.Workers = cast.ToInt(strings.TrimSpace(parts[1]))
+		}
+		if len(parts) > 2 {
+			q.Weight = cast.ToInt(strings.TrimSpace(parts[2]))
+		}
+		if q.Name == "" || len(parts) > 3 || q.Workers < 0 || q.Weight < 0 {
+			log.Printf("WORKER_QUEUES: noto'g'ri navbat sozlamasi o'tkazib yuborildi: %q", item)
+			continue
+		}
+		queues = append(queues, q)
+	}
+	return queues
+}
+
 func coalesce(key string, value interface{}) interface{} {
 	val, exist := os.LookupEnv(key)
 	if exist {
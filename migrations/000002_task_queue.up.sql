@@ -0,0 +1,3 @@
+-- Navbatdan task olish (ClaimTask) uchun index
+CREATE INDEX idx_tasks_queue ON tasks(priority DESC, created_at)
+    WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+-- Task turi: qaysi handler bajarishini belgilaydi
+ALTER TABLE tasks ADD COLUMN type VARCHAR(100) NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_tasks_type ON tasks(type);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_recurring_tasks_due;
+DROP INDEX IF EXISTS idx_tasks_recurring_run;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS recurring_task_id;
+
+DROP TABLE IF EXISTS recurring_tasks;
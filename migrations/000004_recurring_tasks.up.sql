@@ -0,0 +1,29 @@
+-- Takrorlanuvchi (cron) tasklar jadvali
+CREATE TABLE recurring_tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title VARCHAR(500) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    priority INTEGER NOT NULL DEFAULT 3 CHECK (priority BETWEEN 1 AND 5),
+    payload JSONB,
+    max_retries INTEGER NOT NULL DEFAULT 3,
+    cron_expr VARCHAR(100) NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    paused BOOLEAN NOT NULL DEFAULT false,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL
+);
+
+-- Har bir ishga tushish oddiy task sifatida yaratiladi
+ALTER TABLE tasks ADD COLUMN recurring_task_id UUID DEFAULT NULL REFERENCES recurring_tasks(id) ON DELETE SET NULL;
+
+-- Bir nechta instance bir vaqtning o'zida ishga tushirsa ham bitta vaqt uchun bitta task
+CREATE UNIQUE INDEX idx_tasks_recurring_run ON tasks(recurring_task_id, scheduled_at)
+    WHERE recurring_task_id IS NOT NULL;
+
+CREATE INDEX idx_recurring_tasks_due ON recurring_tasks(next_run_at)
+    WHERE paused = false AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_tasks_lease;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS lease_expires_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS claimed_by;
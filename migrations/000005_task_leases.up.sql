@@ -0,0 +1,6 @@
+-- Band qilingan task egasi va lease muddati (worker qulab tushsa task qaytariladi)
+ALTER TABLE tasks ADD COLUMN claimed_by VARCHAR(255) DEFAULT NULL;
+ALTER TABLE tasks ADD COLUMN lease_expires_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+CREATE INDEX idx_tasks_lease ON tasks(lease_expires_at)
+    WHERE status = 'processing';
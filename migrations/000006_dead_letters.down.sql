@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_dead_lettered_at;
+DROP VIEW IF EXISTS dead_letter_tasks;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS dead_lettered_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS error_history;
+ALTER TABLE tasks DROP COLUMN IF EXISTS last_error;
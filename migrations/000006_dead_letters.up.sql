@@ -0,0 +1,20 @@
+-- Oxirgi xato va barcha xatolar tarixi
+ALTER TABLE tasks ADD COLUMN last_error TEXT DEFAULT NULL;
+ALTER TABLE tasks ADD COLUMN error_history JSONB NOT NULL DEFAULT '[]';
+-- Task "failed" holatiga o'tgan (dead-letter navbatiga tushgan) vaqt
+ALTER TABLE tasks ADD COLUMN dead_lettered_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+UPDATE tasks SET dead_lettered_at = updated_at WHERE status = 'failed';
+
+-- Dead-letter navbati: barcha qayta urinishlari tugagan tasklar
+CREATE VIEW dead_letter_tasks AS
+    SELECT
+        id, creator_id, user_id, title, type, priority, payload,
+        retries, max_retries, last_error, error_history, dead_lettered_at
+    FROM tasks
+    WHERE status = 'failed'
+        AND dead_lettered_at IS NOT NULL
+        AND deleted_at IS NULL;
+
+CREATE INDEX idx_tasks_dead_lettered_at ON tasks(dead_lettered_at)
+    WHERE dead_lettered_at IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Taskning har bir bajarilishi (urinishi) tarixi
+CREATE TABLE task_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    attempt INT NOT NULL,
+    worker_id VARCHAR(255) NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    duration_ms BIGINT DEFAULT NULL,
+    outcome VARCHAR(20) NOT NULL DEFAULT 'running'
+        CHECK (outcome IN ('running', 'succeeded', 'failed', 'released', 'lease_lost')),
+    error TEXT DEFAULT NULL,
+    UNIQUE (task_id, attempt)
+);
+
+CREATE INDEX idx_task_attempts_running ON task_attempts(task_id)
+    WHERE outcome = 'running';
@@ -0,0 +1,2 @@
+ALTER TABLE recurring_tasks DROP COLUMN IF EXISTS retry_policy;
+ALTER TABLE tasks DROP COLUMN IF EXISTS retry_policy;
@@ -0,0 +1,4 @@
+-- Qayta urinish siyosati: {"strategy": "exponential", "delay": 1, "max_delay": 3600, "jitter": 0.2}
+-- NULL bo'lsa standart siyosat (exponential) qo'llaniladi
+ALTER TABLE tasks ADD COLUMN retry_policy JSONB DEFAULT NULL;
+ALTER TABLE recurring_tasks ADD COLUMN retry_policy JSONB DEFAULT NULL;
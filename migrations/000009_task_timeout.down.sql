@@ -0,0 +1,7 @@
+UPDATE task_attempts SET outcome = 'failed' WHERE outcome = 'timed_out';
+ALTER TABLE task_attempts DROP CONSTRAINT task_attempts_outcome_check;
+ALTER TABLE task_attempts ADD CONSTRAINT task_attempts_outcome_check
+    CHECK (outcome IN ('running', 'succeeded', 'failed', 'released', 'lease_lost'));
+
+ALTER TABLE recurring_tasks DROP COLUMN IF EXISTS timeout_seconds;
+ALTER TABLE tasks DROP COLUMN IF EXISTS timeout_seconds;
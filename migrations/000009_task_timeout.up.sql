@@ -0,0 +1,8 @@
+-- Taskning maksimal bajarilish vaqti (soniya). 0 - WORKER_TASK_TIMEOUT ishlatiladi
+ALTER TABLE tasks ADD COLUMN timeout_seconds INT NOT NULL DEFAULT 0 CHECK (timeout_seconds >= 0);
+ALTER TABLE recurring_tasks ADD COLUMN timeout_seconds INT NOT NULL DEFAULT 0 CHECK (timeout_seconds >= 0);
+
+-- Vaqti tugagan urinishlar alohida natija sifatida yoziladi
+ALTER TABLE task_attempts DROP CONSTRAINT task_attempts_outcome_check;
+ALTER TABLE task_attempts ADD CONSTRAINT task_attempts_outcome_check
+    CHECK (outcome IN ('running', 'succeeded', 'failed', 'timed_out', 'released', 'lease_lost'));
@@ -0,0 +1,14 @@
+UPDATE task_attempts SET outcome = 'failed' WHERE outcome = 'cancelled';
+ALTER TABLE task_attempts DROP CONSTRAINT task_attempts_outcome_check;
+ALTER TABLE task_attempts ADD CONSTRAINT task_attempts_outcome_check
+    CHECK (outcome IN ('running', 'succeeded', 'failed', 'timed_out', 'released', 'lease_lost'));
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS cancelled_at;
+ALTER TABLE tasks DROP COLUMN IF EXISTS cancelled_by;
+ALTER TABLE tasks DROP COLUMN IF EXISTS cancel_reason;
+
+UPDATE tasks SET status = 'failed' WHERE status = 'cancelled';
+ALTER TABLE tasks DROP CONSTRAINT tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed')
+);
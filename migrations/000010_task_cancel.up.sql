@@ -0,0 +1,14 @@
+-- Bekor qilingan tasklar uchun status
+ALTER TABLE tasks DROP CONSTRAINT tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed', 'cancelled')
+);
+
+-- Kim, qachon va nima sababdan bekor qilgan
+ALTER TABLE tasks ADD COLUMN cancel_reason TEXT DEFAULT NULL;
+ALTER TABLE tasks ADD COLUMN cancelled_by UUID DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE tasks ADD COLUMN cancelled_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+ALTER TABLE task_attempts DROP CONSTRAINT task_attempts_outcome_check;
+ALTER TABLE task_attempts ADD CONSTRAINT task_attempts_outcome_check
+    CHECK (outcome IN ('running', 'succeeded', 'failed', 'timed_out', 'cancelled', 'released', 'lease_lost'));
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS task_dependencies;
+
+UPDATE tasks SET status = 'cancelled' WHERE status = 'skipped';
+ALTER TABLE tasks DROP CONSTRAINT tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed', 'cancelled')
+);
+
+DROP INDEX IF EXISTS idx_tasks_workflow_id;
+ALTER TABLE tasks DROP COLUMN IF EXISTS workflow_id;
+DROP TABLE IF EXISTS workflows;
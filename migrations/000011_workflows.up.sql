@@ -0,0 +1,27 @@
+-- Workflow: bir-biriga bog'liq tasklar guruhi (DAG)
+CREATE TABLE workflows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(500) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tasks ADD COLUMN workflow_id UUID DEFAULT NULL REFERENCES workflows(id) ON DELETE CASCADE;
+CREATE INDEX idx_tasks_workflow_id ON tasks(workflow_id) WHERE workflow_id IS NOT NULL;
+
+-- Ota task muvaffaqiyatsiz tugasa unga bog'liq tasklar "skipped" bo'ladi
+ALTER TABLE tasks DROP CONSTRAINT tasks_status_check;
+ALTER TABLE tasks ADD CONSTRAINT tasks_status_check CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed', 'cancelled', 'skipped')
+);
+
+-- task_id faqat depends_on "completed" bo'lgandan keyin bajariladi
+CREATE TABLE task_dependencies (
+    task_id UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    depends_on UUID NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, depends_on),
+    CHECK (task_id <> depends_on)
+);
+
+CREATE INDEX idx_task_dependencies_depends_on ON task_dependencies(depends_on);
@@ -0,0 +1,16 @@
+-- Batch: bitta so'rovda yaratilgan bog'liq tasklar guruhi
+CREATE TABLE batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(500) NOT NULL,
+    total INT NOT NULL,
+    -- Barcha tasklar tugaganda: {"task": {...}} yoki {"webhook_url": "..."}
+    on_complete JSONB DEFAULT NULL,
+    -- Hook bir marta ishga tushishi uchun belgi
+    completed_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE tasks ADD COLUMN batch_id UUID DEFAULT NULL REFERENCES batches(id) ON DELETE CASCADE;
+CREATE INDEX idx_tasks_batch_id ON tasks(batch_id, status) WHERE batch_id IS NOT NULL;
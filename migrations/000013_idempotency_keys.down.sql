@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_idempotency_key;
+ALTER TABLE tasks DROP COLUMN IF EXISTS idempotency_key;
@@ -0,0 +1,7 @@
+-- Mijoz qayta yuborgan so'rov yangi task yaratmasligi uchun kalit
+ALTER TABLE tasks ADD COLUMN idempotency_key VARCHAR(255) DEFAULT NULL;
+
+-- Kalit har bir yaratuvchi uchun unikal. Saqlash muddati o'tgach kalit
+-- tozalanadi (NULL qilinadi) va uni qayta ishlatish mumkin bo'ladi.
+CREATE UNIQUE INDEX idx_tasks_idempotency_key ON tasks(creator_id, idempotency_key)
+    WHERE idempotency_key IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_tasks_unique_key;
+ALTER TABLE tasks DROP COLUMN IF EXISTS unique_key;
@@ -0,0 +1,9 @@
+-- type + payload (yoki foydalanuvchi kaliti) xeshi: bir xil ish bir vaqtda ikki marta bajarilmaydi
+ALTER TABLE tasks ADD COLUMN unique_key VARCHAR(64) DEFAULT NULL;
+
+-- Faqat navbatdagi va bajarilayotgan tasklar orasida unikal: task tugagach
+-- xuddi shunday task qayta yaratilishi mumkin
+CREATE UNIQUE INDEX idx_tasks_unique_key ON tasks(unique_key)
+    WHERE unique_key IS NOT NULL
+        AND status IN ('pending', 'processing')
+        AND deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- Task turi bo'yicha cheklovlar: bir vaqtda bajariladigan tasklar soni va sekundiga ishga tushirish tezligi.
+-- 0 - cheklovsiz. Admin endpoint orqali o'zgartiriladi, workerlar muntazam qayta yuklaydi.
+CREATE TABLE task_type_limits (
+    type VARCHAR(100) PRIMARY KEY,
+    max_concurrency INT NOT NULL DEFAULT 0 CHECK (max_concurrency >= 0),
+    rate_per_second DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (rate_per_second >= 0),
+    burst INT NOT NULL DEFAULT 0 CHECK (burst >= 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
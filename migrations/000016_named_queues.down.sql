@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_tasks_queue;
+CREATE INDEX idx_tasks_queue ON tasks(priority DESC, created_at)
+    WHERE status = 'pending' AND deleted_at IS NULL;
+
+ALTER TABLE recurring_tasks DROP COLUMN IF EXISTS queue;
+ALTER TABLE tasks DROP COLUMN IF EXISTS queue;
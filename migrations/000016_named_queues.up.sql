@@ -0,0 +1,8 @@
+-- Nomlangan navbatlar: workerlar faqat o'zlariga biriktirilgan navbatlardan task oladi
+ALTER TABLE tasks ADD COLUMN queue VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE recurring_tasks ADD COLUMN queue VARCHAR(100) NOT NULL DEFAULT 'default';
+
+-- ClaimTask endi navbat bo'yicha ham filtrlaydi
+DROP INDEX IF EXISTS idx_tasks_queue;
+CREATE INDEX idx_tasks_queue ON tasks(queue, priority DESC, created_at)
+    WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- To'xtatilgan navbatlar: workerlar ulardan yangi task olmaydi, bajarilayotganlari tugaydi
+CREATE TABLE paused_queues (
+    queue VARCHAR(100) PRIMARY KEY,
+    paused_by UUID DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+    paused_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+-- Worker jarayonlarining oxirgi holati (admin dashboard uchun): har bir instance
+-- o'z holatini muntazam yozadi, uzoq yangilanmagan yozuv o'chgan instance hisoblanadi
+CREATE TABLE worker_instances (
+    instance_id VARCHAR(255) PRIMARY KEY,
+    state JSONB NOT NULL,
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
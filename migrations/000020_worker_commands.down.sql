@@ -0,0 +1 @@
+DROP TABLE IF EXISTS worker_commands;
@@ -0,0 +1,15 @@
+-- Admin buyruqlari worker jarayonlariga (workerlar sonini o'zgartirish, navbatni
+-- to'xtatish/davom ettirish). Yozilgach NOTIFY yuboriladi, workerlar o'zlariga
+-- tegishli yangi buyruqlarni shu jadvaldan o'qiydi.
+CREATE TABLE worker_commands (
+    id BIGSERIAL PRIMARY KEY,
+    -- NULL bo'lsa barcha worker jarayonlari uchun
+    instance_id VARCHAR(255) DEFAULT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('scale', 'pause', 'resume')),
+    queue VARCHAR(100) NOT NULL DEFAULT '',
+    workers INT DEFAULT NULL CHECK (workers >= 0),
+    created_by UUID DEFAULT NULL REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_worker_commands_created_at ON worker_commands(created_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_tasks_queue;
+CREATE INDEX idx_tasks_queue ON tasks(queue, priority DESC, created_at)
+    WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- ClaimTask har bir (navbat, priority) juftligidan eng uzoq kutgan taskni oladi:
+-- index shu tartibda, aks holda har bir claim barcha kutayotgan tasklarni saralaydi.
+-- Ifoda storage/postgres dagi readyAtExpr bilan bir xil bo'lishi kerak.
+DROP INDEX IF EXISTS idx_tasks_queue;
+CREATE INDEX idx_tasks_queue ON tasks(queue, priority, (COALESCE(GREATEST(scheduled_at, next_retry_at), created_at)))
+    WHERE status = 'pending' AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- ClaimTask max_concurrency ni tekshirish uchun har bir claimda turdagi bajarilayotgan tasklarni sanaydi
+CREATE INDEX idx_tasks_processing_type ON tasks(type)
+    WHERE status = 'processing' AND deleted_at IS NULL;
@@ -0,0 +1,10 @@
+DELETE FROM webhook_deliveries WHERE batch_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_batch;
+ALTER TABLE webhook_deliveries
+    DROP CONSTRAINT IF EXISTS webhook_deliveries_owner,
+    DROP COLUMN IF EXISTS batch_id,
+    ALTER COLUMN webhook_id SET NOT NULL,
+    ALTER COLUMN task_id SET NOT NULL;
+
+ALTER TABLE batches DROP COLUMN IF EXISTS hook_secret;
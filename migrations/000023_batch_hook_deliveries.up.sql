@@ -0,0 +1,11 @@
+-- Batch on_complete webhooki ham webhook_deliveries orqali yuboriladi: imzolanadi,
+-- qayta uriniladi va natijasi saqlanadi. Bunday yuborish webhookka emas, batchga tegishli.
+ALTER TABLE batches ADD COLUMN hook_secret VARCHAR(255) DEFAULT NULL;
+
+ALTER TABLE webhook_deliveries
+    ALTER COLUMN webhook_id DROP NOT NULL,
+    ALTER COLUMN task_id DROP NOT NULL,
+    ADD COLUMN batch_id UUID DEFAULT NULL REFERENCES batches(id) ON DELETE CASCADE,
+    ADD CONSTRAINT webhook_deliveries_owner CHECK ((webhook_id IS NULL) <> (batch_id IS NULL));
+
+CREATE INDEX idx_webhook_deliveries_batch ON webhook_deliveries (batch_id, created_at DESC) WHERE batch_id IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE task_type_limits ALTER COLUMN updated_at TYPE TIMESTAMP;
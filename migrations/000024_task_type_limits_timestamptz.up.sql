@@ -0,0 +1,2 @@
+-- task_type_limits.updated_at boshqa jadvallar kabi vaqt zonasi bilan saqlanadi
+ALTER TABLE task_type_limits ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+ALTER TABLE webhooks
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at TYPE TIMESTAMP;
@@ -0,0 +1,4 @@
+-- webhooks vaqtlari webhook_deliveries kabi vaqt zonasi bilan saqlanadi
+ALTER TABLE webhooks
+    ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE,
+    ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,27 @@
+package db
+
+import "time"
+
+// Urinish natijalari
+const (
+	AttemptRunning   = "running"    // Hali bajarilmoqda
+	AttemptSucceeded = "succeeded"  // Handler xatosiz tugadi
+	AttemptFailed    = "failed"     // Handler xato qaytardi
+	AttemptTimedOut  = "timed_out"  // Task timeouti tugadi
+	AttemptCancelled = "cancelled"  // Task bajarilayotganda bekor qilindi
+	AttemptReleased  = "released"   // Worker to'xtatilayotganda task navbatga qaytarildi
+	AttemptLeaseLost = "lease_lost" // Heartbeat kelmadi, task reaper tomonidan qaytarildi
+)
+
+// TaskAttempt - taskning bitta bajarilishi
+type TaskAttempt struct {
+	ID         string     `json:"id"`
+	TaskID     string     `json:"task_id"`
+	Attempt    int        `json:"attempt"` // Task bo'yicha tartib raqami (1 dan boshlanadi)
+	WorkerID   string     `json:"worker_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	DurationMs *int64     `json:"duration_ms,omitempty"`
+	Outcome    string     `json:"outcome"`
+	Error      *string    `json:"error,omitempty"`
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Batch - bitta so'rovda yaratilgan tasklar guruhi
+type Batch struct {
+	ID          string     `json:"id"`
+	CreatorID   string     `json:"creator_id"`
+	Name        string     `json:"name"`
+	Total       int        `json:"total"`
+	OnComplete  *BatchHook `json:"on_complete,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // Barcha tasklar tugagan (hook ishga tushgan) vaqt
+	// webhook_url hooki so'rovlarini imzolash kaliti (webhooklardagi kabi X-Webhook-Signature).
+	// Faqat batch yaratish javobida qaytariladi.
+	HookSecret string    `json:"hook_secret,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// BatchHook - batchdagi barcha tasklar tugaganda bir marta bajariladigan amal:
+// yangi task yaratish yoki webhookga POST yuborish
+type BatchHook struct {
+	Task       *BatchHookTask `json:"task,omitempty"`
+	WebhookURL string         `json:"webhook_url,omitempty"`
+}
+
+// BatchHookTask - hook sifatida yaratiladigan task. Payloadga "batch_id" qo'shiladi.
+type BatchHookTask struct {
+	Title      string          `json:"title"`
+	Type       string          `json:"type"`
+	Priority   int             `json:"priority,omitempty"`
+	Queue      string          `json:"queue,omitempty"`
+	Payload    json.RawMessage `json:"payload,omitempty" swaggertype:"object"`
+	MaxRetries int             `json:"max_retries,omitempty"`
+}
+
+// Value - JSONB ustunga yozish
+func (h BatchHook) Value() (driver.Value, error) {
+	return json.Marshal(h)
+}
+
+// Scan - JSONB ustundan o'qish
+func (h *BatchHook) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("on_complete: kutilmagan tur %T", src)
+	}
+	return json.Unmarshal(b, h)
+}
+
+// BatchStatus - batch va tasklari bo'yicha umumiy holat
+type BatchStatus struct {
+	Batch
+	Counts   map[string]int `json:"counts"`   // task statusi -> soni
+	Finished bool           `json:"finished"` // Hech bir task pending yoki processing emas
+	// HookDelivery - on_complete webhookining yuborilishi (urinishlar, oxirgi javob)
+	HookDelivery *WebhookDelivery `json:"hook_delivery,omitempty"`
+}
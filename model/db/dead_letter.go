@@ -0,0 +1,29 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TaskError - taskning bitta muvaffaqiyatsiz urinishi
+type TaskError struct {
+	Attempt int       `json:"attempt"`
+	Error   string    `json:"error"`
+	At      time.Time `json:"at"`
+}
+
+// DeadLetter - barcha qayta urinishlari tugagan (failed) task
+type DeadLetter struct {
+	TaskID         string          `json:"task_id"`
+	CreatorID      string          `json:"creator_id"`
+	UserID         string          `json:"user_id"`
+	Title          string          `json:"title"`
+	Type           string          `json:"type"`
+	Priority       int             `json:"priority"`
+	Payload        json.RawMessage `json:"payload"`
+	Retries        int             `json:"retries"`
+	MaxRetries     int             `json:"max_retries"`
+	LastError      string          `json:"last_error"`
+	ErrorHistory   []TaskError     `json:"error_history"`
+	DeadLetteredAt time.Time       `json:"dead_lettered_at"`
+}
@@ -0,0 +1,17 @@
+package db
+
+import "time"
+
+// TaskEvent - task holati o'zgargani haqida xabar (SSE orqali mijozlarga yuboriladi)
+type TaskEvent struct {
+	TaskID    string    `json:"task_id"`
+	CreatorID string    `json:"creator_id"`
+	UserID    string    `json:"user_id"`
+	Type      string    `json:"type"`
+	Queue     string    `json:"queue"`
+	Status    string    `json:"status"`
+	Retries   int       `json:"retries"`
+	Worker    *string   `json:"worker,omitempty"` // Taskni bajarayotgan worker (processing da)
+	Error     *string   `json:"error,omitempty"`  // Oxirgi urinish xatosi (qisqartirilgan)
+	At        time.Time `json:"at"`
+}
@@ -0,0 +1,19 @@
+package db
+
+import "time"
+
+// TaskTypeLimit - task turi bo'yicha cheklov. 0 qiymat - cheklovsiz.
+type TaskTypeLimit struct {
+	Type           string    `json:"type"`
+	MaxConcurrency int       `json:"max_concurrency"` // Shu turdagi bir vaqtda bajariladigan tasklar soni (barcha instancelarda jami)
+	RatePerSecond  float64   `json:"rate_per_second"` // Sekundiga ishga tushiriladigan tasklar soni (har bir worker jarayonida)
+	Burst          int       `json:"burst"`           // Ketma-ket ishga tushirish mumkin bo'lgan tasklar (0 - rate_per_second ga teng)
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TaskTypeLimitStatus - cheklov va uning joriy holati
+type TaskTypeLimitStatus struct {
+	TaskTypeLimit
+	Running int     `json:"running"` // Hozir barcha instancelarda bajarilayotgan shu turdagi tasklar
+	Tokens  float64 `json:"tokens"`  // Darhol ishga tushirish mumkin bo'lgan tasklar (rate cheklovi bo'lsa)
+}
@@ -0,0 +1,30 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// RecurringTask - cron ifodasi bo'yicha vaqti-vaqti bilan oddiy task yaratuvchi ta'rif
+type RecurringTask struct {
+	ID             string          `json:"id"`
+	CreatorID      string          `json:"creator_id"`
+	UserID         string          `json:"user_id"`
+	Title          string          `json:"title"`
+	Type           string          `json:"type"`
+	Priority       int             `json:"priority"`
+	Queue          string          `json:"queue"`
+	Payload        json.RawMessage `json:"payload"`
+	MaxRetries     int             `json:"max_retries"`
+	RetryPolicy    *RetryPolicy    `json:"retry_policy,omitempty"`
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"`
+	CronExpr       string          `json:"cron_expr"` // Masalan: "0 * * * *" yoki "@daily"
+	Timezone       string          `json:"timezone"`  // IANA nomi, masalan: "Asia/Tashkent"
+	Paused         bool            `json:"paused"`
+	NextRunAt      time.Time       `json:"next_run_at"`
+	LastRunAt      sql.NullTime    `json:"last_run_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      sql.NullTime    `json:"deleted_at"`
+}
@@ -0,0 +1,38 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Qayta urinish strategiyalari
+const (
+	RetryNone        = "none"        // Birinchi xatodayoq task failed bo'ladi
+	RetryFixed       = "fixed"       // Har safar bir xil kechikish
+	RetryLinear      = "linear"      // delay * urinishlar soni
+	RetryExponential = "exponential" // delay * 2^urinishlar soni
+)
+
+// RetryPolicy - task xato bilan tugaganda qachon qayta urinish kerakligi.
+// Task bilan birga saqlanadi, shuning uchun restartdan keyin ham bir xil ishlaydi.
+type RetryPolicy struct {
+	Strategy string  `json:"strategy"`
+	Delay    int     `json:"delay,omitempty"`     // Soniya: asosiy kechikish
+	MaxDelay int     `json:"max_delay,omitempty"` // Soniya: kechikishning yuqori chegarasi (0 - 7 kun)
+	Jitter   float64 `json:"jitter,omitempty"`    // 0..1: kechikishning tasodifiy kamaytiriladigan ulushi
+}
+
+// Value - JSONB ustunga yozish
+func (p RetryPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan - JSONB ustundan o'qish
+func (p *RetryPolicy) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("retry_policy: kutilmagan tur %T", src)
+	}
+	return json.Unmarshal(b, p)
+}
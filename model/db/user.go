@@ -12,14 +12,30 @@ type Task struct {
 	CreatorID           string          `json:"creator_id"`
 	UserID              string          `json:"user_id"`
 	Title               string          `json:"title"`
-	Priority            int             `json:"priority"`
+	Type                string          `json:"type"`     // Qaysi TaskHandler bajarishini belgilaydi
+	Priority            int             `json:"priority"` // 1-5, kattaroq qiymat birinchi bajariladi
 	Status              string          `json:"status"`
 	CanUserChangeStatus bool            `json:"can_user_change_status"`
 	Payload             json.RawMessage `json:"payload"`
+	Queue               string          `json:"queue"` // Nomlangan navbat: "default", "critical", "bulk" ...
 	Retries             int             `json:"retries"`
 	MaxRetries          int             `json:"max_retries"`
+	RetryPolicy         *RetryPolicy    `json:"retry_policy,omitempty"`    // nil bo'lsa standart exponential siyosat
+	TimeoutSeconds      int             `json:"timeout_seconds,omitempty"` // 0 bo'lsa WORKER_TASK_TIMEOUT
 	ScheduledAt         sql.NullTime    `json:"scheduled_at"`
 	NextRetryAt         *time.Time      `json:"next_retry_at"` // Yangi qo'shilgan maydon
+	RecurringTaskID     *string         `json:"recurring_task_id,omitempty"`
+	WorkflowID          *string         `json:"workflow_id,omitempty"`
+	BatchID             *string         `json:"batch_id,omitempty"`
+	IdempotencyKey      *string         `json:"idempotency_key,omitempty"`  // Takroriy so'rov shu kalit bo'yicha aniqlanadi
+	UniqueKey           *string         `json:"unique_key,omitempty"`       // Shu kalitli pending/processing task bitta bo'ladi
+	ClaimedBy           *string         `json:"claimed_by,omitempty"`       // Taskni bajarayotgan worker
+	LeaseExpiresAt      *time.Time      `json:"lease_expires_at,omitempty"` // Heartbeat kelmasa shu vaqtdan keyin task qaytariladi
+	LastError           *string         `json:"last_error,omitempty"`       // Oxirgi muvaffaqiyatsiz urinish xatosi
+	DeadLetteredAt      *time.Time      `json:"dead_lettered_at,omitempty"` // Task dead-letter navbatiga tushgan vaqt
+	CancelReason        *string         `json:"cancel_reason,omitempty"`
+	CancelledBy         *string         `json:"cancelled_by,omitempty"`
+	CancelledAt         *time.Time      `json:"cancelled_at,omitempty"`
 	CreatedAt           time.Time       `json:"created_at"`
 	UpdatedAt           time.Time       `json:"updated_at"`
 	DeletedAt           sql.NullTime    `json:"deleted_at"`
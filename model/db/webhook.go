@@ -0,0 +1,45 @@
+package db
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook yuborish holatlari
+const (
+	DeliveryPending   = "pending"   // Yuborilmagan yoki qayta urinish kutilmoqda
+	DeliverySucceeded = "succeeded" // Qabul qiluvchi 2xx qaytardi
+	DeliveryFailed    = "failed"    // Urinishlar tugadi
+)
+
+// Webhook - task hodisalari yuboriladigan foydalanuvchi URL manzili
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"` // Faqat yaratilganda qaytariladi
+	Events    []string  `json:"events"`           // Bo'sh bo'lsa barcha hodisalar
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery - bitta hodisani webhookka yuborish va uning urinishlari
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id,omitempty"` // Batch hooki uchun bo'sh
+	TaskID         string          `json:"task_id,omitempty"`
+	BatchID        *string         `json:"batch_id,omitempty"` // Batch on_complete webhooki
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+
+	// Yuborish uchun webhookdan olinadi
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
@@ -0,0 +1,84 @@
+package db
+
+import "time"
+
+// Worker holatlari
+const (
+	WorkerIdle     = "idle"
+	WorkerBusy     = "busy"
+	WorkerStopping = "stopping" // Pool kichraytirilgan: joriy taskni tugatib chiqadi
+)
+
+// PausedQueue - to'xtatilgan navbat
+type PausedQueue struct {
+	Queue    string    `json:"queue"`
+	PausedBy *string   `json:"paused_by,omitempty"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// WorkerState - bitta workerning joriy holati
+type WorkerState struct {
+	ID       int       `json:"id"`
+	Name     string    `json:"name"`            // claimed_by ga yoziladigan nom
+	Queue    string    `json:"queue,omitempty"` // Alohida worker navbati; umumiy worker uchun bo'sh
+	State    string    `json:"state"`
+	TaskID   string    `json:"task_id,omitempty"`
+	TaskType string    `json:"task_type,omitempty"`
+	Since    time.Time `json:"since"` // Joriy holatga o'tgan vaqt
+}
+
+// QueueState - navbat va unga xizmat qiluvchi alohida workerlar
+type QueueState struct {
+	Name    string `json:"name"`
+	Workers int    `json:"workers"` // Alohida workerlar soni
+	Weight  int    `json:"weight"`  // Umumiy workerlar uchun og'irlik
+	Paused  bool   `json:"paused"`
+}
+
+// PoolState - shu jarayondagi worker pool holati
+type PoolState struct {
+	InstanceID    string        `json:"instance_id"`
+	ReportedAt    *time.Time    `json:"reported_at,omitempty"` // Bazadan o'qilganda: holat yozilgan vaqt
+	Running       bool          `json:"running"`               // Shu instanceda workerlar ishlayaptimi (faqat API bo'lsa false)
+	SharedWorkers int           `json:"shared_workers"`        // Og'irlik bo'yicha barcha navbatlarga xizmat qiluvchilar
+	Queues        []QueueState  `json:"queues"`
+	Workers       []WorkerState `json:"workers"`
+}
+
+// QueueDepth - navbatdagi tasklar soni
+type QueueDepth struct {
+	Queue      string `json:"queue"`
+	Pending    int    `json:"pending"`
+	Ready      int    `json:"ready"` // Pending lardan hozir bajarilishi mumkinlari
+	Processing int    `json:"processing"`
+	Paused     bool   `json:"paused"`
+	// Consumers - ishlayotgan worker jarayonlarida shu navbatdan task oladigan workerlar soni.
+	// 0 bo'lsa navbatga hech kim xizmat qilmaydi va tasklar bajarilmay kutib qoladi.
+	Consumers int `json:"consumers"`
+}
+
+// DashboardSnapshot - admin dashboard uchun navbatlar, workerlar va bajarilayotgan tasklar
+type DashboardSnapshot struct {
+	Queues    []QueueDepth `json:"queues"`
+	Instances []PoolState  `json:"instances"` // Yaqinda holat yuborgan worker jarayonlari
+	Running   []Task       `json:"running"`
+	At        time.Time    `json:"at"`
+}
+
+// Worker jarayonlariga yuboriladigan buyruqlar
+const (
+	WorkerCommandScale  = "scale"  // Navbat (yoki umumiy) workerlari sonini o'zgartirish
+	WorkerCommandPause  = "pause"  // To'xtatilgan navbatlarni qayta yuklash
+	WorkerCommandResume = "resume" // To'xtatilgan navbatlarni qayta yuklash va workerlarni uyg'otish
+)
+
+// WorkerCommand - API dan worker jarayonlariga yuborilgan buyruq
+type WorkerCommand struct {
+	ID         int64     `json:"id"`
+	InstanceID *string   `json:"instance_id,omitempty"` // Bo'sh bo'lsa barcha worker jarayonlari
+	Action     string    `json:"action"`
+	Queue      string    `json:"queue,omitempty"` // scale da bo'sh bo'lsa umumiy workerlar
+	Workers    *int      `json:"workers,omitempty"`
+	CreatedBy  *string   `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package db
+
+import "time"
+
+// Workflow - bir-biriga bog'liq tasklar guruhi (DAG)
+type Workflow struct {
+	ID        string    `json:"id"`
+	CreatorID string    `json:"creator_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskDependency - TaskID faqat DependsOn muvaffaqiyatli tugagandan keyin bajariladi
+type TaskDependency struct {
+	TaskID    string `json:"task_id"`
+	DependsOn string `json:"depends_on"`
+}
+
+// WorkflowStatus - workflow va uning tasklari bo'yicha umumiy holat
+type WorkflowStatus struct {
+	Workflow
+	Status       string           `json:"status"` // running, completed yoki failed
+	Counts       map[string]int   `json:"counts"` // task statusi -> soni
+	Tasks        []Task           `json:"tasks"`
+	Dependencies []TaskDependency `json:"dependencies"`
+}
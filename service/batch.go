@@ -0,0 +1,220 @@
+// service/batch_service.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBatch - batch ta'rifi noto'g'ri
+var ErrInvalidBatch = errors.New("batch ma'lumotlari noto'g'ri")
+
+// maxBatchSize - bitta batchdagi tasklarning maksimal soni
+const maxBatchSize = 1000
+
+// CreateBatch - barcha tasklarni bitta tranzaksiyada yaratish va navbatga qo'yish
+func (s *TaskService) CreateBatch(ctx context.Context, creatorID, name string, tasks []db.Task, onComplete *db.BatchHook) (*db.BatchStatus, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name bo'sh bo'lishi mumkin emas", ErrInvalidBatch)
+	}
+	if len(tasks) == 0 || len(tasks) > maxBatchSize {
+		return nil, fmt.Errorf("%w: tasklar soni 1 dan %d gacha bo'lishi kerak", ErrInvalidBatch, maxBatchSize)
+	}
+	if err := validateBatchHook(onComplete); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := db.Batch{
+		ID:         uuid.NewString(),
+		CreatorID:  creatorID,
+		Name:       name,
+		Total:      len(tasks),
+		OnComplete: onComplete,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if onComplete != nil && onComplete.WebhookURL != "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		batch.HookSecret = secret
+	}
+
+	for i := range tasks {
+		if err := validateTask(&tasks[i]); err != nil {
+			return nil, fmt.Errorf("%w: %d-task: %v", ErrInvalidBatch, i+1, err)
+		}
+		tasks[i].ID = uuid.NewString()
+		tasks[i].CreatorID = creatorID
+		tasks[i].BatchID = &batch.ID
+		tasks[i].Status = "pending"
+		tasks[i].CreatedAt = now
+		tasks[i].UpdatedAt = now
+	}
+
+	if err := s.storage.Batch().CreateBatch(ctx, batch, tasks); err != nil {
+		s.logger.Error("Batchni saqlashda xato", "error", err)
+		return nil, fmt.Errorf("batchni saqlashda xato: %w", err)
+	}
+
+	for i := range tasks {
+		s.workerPool.Enqueue(&tasks[i])
+	}
+	s.logger.Info("Batch yaratildi", "batch_id", batch.ID, "tasks", len(tasks))
+
+	status, err := s.GetBatch(ctx, batch.ID)
+	if err != nil {
+		return nil, err
+	}
+	status.HookSecret = batch.HookSecret
+	return status, nil
+}
+
+// validateBatchHook - on_complete da task yoki webhook_url dan faqat bittasi bo'lishi kerak
+func validateBatchHook(hook *db.BatchHook) error {
+	if hook == nil {
+		return nil
+	}
+	if (hook.Task == nil) == (hook.WebhookURL == "") {
+		return fmt.Errorf("%w: on_complete da task yoki webhook_url dan bittasi bo'lishi kerak", ErrInvalidBatch)
+	}
+
+	if hook.Task != nil {
+		template := db.Task{
+			Title:      hook.Task.Title,
+			Type:       hook.Task.Type,
+			Priority:   hook.Task.Priority,
+			Queue:      hook.Task.Queue,
+			MaxRetries: hook.Task.MaxRetries,
+		}
+		if err := validateTask(&template); err != nil {
+			return fmt.Errorf("%w: on_complete task: %v", ErrInvalidBatch, err)
+		}
+		hook.Task.Priority = template.Priority
+		hook.Task.Queue = template.Queue
+		hook.Task.MaxRetries = template.MaxRetries
+		return nil
+	}
+
+	u, err := url.Parse(hook.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: webhook_url noto'g'ri", ErrInvalidBatch)
+	}
+	return nil
+}
+
+// GetBatch - batch va uning tasklari bo'yicha statistika
+func (s *TaskService) GetBatch(ctx context.Context, id string) (*db.BatchStatus, error) {
+	batch, err := s.storage.Batch().GetBatch(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrBatchNotFound) {
+			s.logger.Error("Batchni olishda xato", "batch_id", id, "error", err)
+		}
+		return nil, err
+	}
+
+	counts, err := s.storage.Batch().CountByStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &db.BatchStatus{
+		Batch:    batch,
+		Counts:   counts,
+		Finished: counts["pending"]+counts["processing"] == 0,
+	}
+	if batch.CompletedAt != nil && batch.OnComplete != nil && batch.OnComplete.WebhookURL != "" {
+		status.HookDelivery, err = s.storage.Webhook().GetBatchDelivery(ctx, id)
+		if err != nil {
+			s.logger.Error("Batch hooki yuborilishini olishda xato", "batch_id", id, "error", err)
+		}
+	}
+	return status, nil
+}
+
+// batchTaskFinished - batch taski tugaganda (yoki o'chirilganda): bu oxirgi task bo'lsa
+// on_complete hookini ishga tushirish. Webhook hooki webhook_deliveries orqali
+// imzolangan holda yuboriladi va muvaffaqiyatsiz bo'lsa qayta uriniladi.
+func (s *TaskService) batchTaskFinished(task *db.Task) {
+	if task.BatchID == nil {
+		return
+	}
+
+	ctx := context.Background()
+	status, err := s.GetBatch(ctx, *task.BatchID)
+	if err != nil || !status.Finished || status.CompletedAt != nil {
+		return
+	}
+
+	now := time.Now()
+	var delivery *db.WebhookDelivery
+	if status.OnComplete != nil && status.OnComplete.WebhookURL != "" {
+		status.CompletedAt = &now
+		status.UpdatedAt = now
+		payload, err := json.Marshal(BatchWebhookPayload{Event: BatchCompletedEvent, Data: *status})
+		if err != nil {
+			s.logger.Error("Batch holatini kodlashda xato", "batch_id", status.ID, "error", err)
+			return
+		}
+		delivery = &db.WebhookDelivery{Event: BatchCompletedEvent, Payload: payload}
+	}
+
+	batch, completed, err := s.storage.Batch().MarkCompleted(ctx, status.ID, now, delivery)
+	if err != nil {
+		s.logger.Error("Batch holatini tekshirishda xato", "batch_id", status.ID, "error", err)
+		return
+	}
+	if !completed {
+		return
+	}
+
+	s.logger.Info("Batch yakunlandi", "batch_id", batch.ID)
+	if delivery != nil {
+		s.logger.Info("Batch webhooki yuborish navbatiga qo'yildi", "batch_id", batch.ID)
+		return
+	}
+	if batch.OnComplete != nil && batch.OnComplete.Task != nil {
+		s.createBatchHookTask(ctx, status)
+	}
+}
+
+// createBatchHookTask - batch tugaganda hook taskini yaratish
+func (s *TaskService) createBatchHookTask(ctx context.Context, status *db.BatchStatus) {
+	hook := status.OnComplete.Task
+
+	payload := map[string]interface{}{}
+	if len(hook.Payload) > 0 && string(hook.Payload) != "null" {
+		if err := json.Unmarshal(hook.Payload, &payload); err != nil {
+			s.logger.Error("Hook payloadini parse qilishda xato", "batch_id", status.ID, "error", err)
+		}
+	}
+	payload["batch_id"] = status.ID
+	payload["counts"] = status.Counts
+	raw, _ := json.Marshal(payload)
+
+	task, err := s.CreateTask(ctx, db.Task{
+		CreatorID:  status.CreatorID,
+		UserID:     status.CreatorID,
+		Title:      hook.Title,
+		Type:       hook.Type,
+		Priority:   hook.Priority,
+		Queue:      hook.Queue,
+		Payload:    raw,
+		MaxRetries: hook.MaxRetries,
+	})
+	if err != nil {
+		s.logger.Error("Batch hook taskini yaratishda xato", "batch_id", status.ID, "error", err)
+		return
+	}
+	s.logger.Info("Batch hook taski yaratildi", "batch_id", status.ID, "task_id", task.ID)
+}
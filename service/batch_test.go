@@ -0,0 +1,163 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fakeBatches - bitta batch: tasklari statuslari bo'yicha sanaladi
+type fakeBatches struct {
+	storage.IBatchStorage
+
+	batch db.Batch
+	tasks map[string]string // task ID -> status (o'chirilganlar yo'q)
+	hooks []*db.WebhookDelivery
+}
+
+func (f *fakeBatches) GetBatch(ctx context.Context, id string) (db.Batch, error) {
+	if id != f.batch.ID {
+		return db.Batch{}, storage.ErrBatchNotFound
+	}
+	return f.batch, nil
+}
+
+func (f *fakeBatches) CountByStatus(ctx context.Context, batchID string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, status := range f.tasks {
+		counts[status]++
+	}
+	return counts, nil
+}
+
+func (f *fakeBatches) MarkCompleted(ctx context.Context, batchID string, completedAt time.Time, hook *db.WebhookDelivery) (db.Batch, bool, error) {
+	for _, status := range f.tasks {
+		if status == "pending" || status == "processing" {
+			return db.Batch{}, false, nil
+		}
+	}
+	if f.batch.CompletedAt != nil {
+		return db.Batch{}, false, nil
+	}
+	f.batch.CompletedAt = &completedAt
+	f.hooks = append(f.hooks, hook)
+	return f.batch, true, nil
+}
+
+// fakeBatchDeliveries - MarkCompleted saqlagan webhook yuborilishi
+type fakeBatchDeliveries struct {
+	storage.IWebhookStorage
+	batches *fakeBatches
+}
+
+func (f *fakeBatchDeliveries) GetBatchDelivery(ctx context.Context, batchID string) (*db.WebhookDelivery, error) {
+	if len(f.batches.hooks) == 0 {
+		return nil, nil
+	}
+	return f.batches.hooks[len(f.batches.hooks)-1], nil
+}
+
+func (f *fakeBatchDeliveries) EnqueueDeliveries(ctx context.Context, userIDs []string, taskID, event string, payload []byte) (int64, error) {
+	return 0, nil
+}
+
+// fakeBatchTasks - DeleteTask uchun task storage
+type fakeBatchTasks struct {
+	storage.ITaskStorage
+	batches *fakeBatches
+}
+
+func (f *fakeBatchTasks) GetTask(ctx context.Context, id string) (db.Task, error) {
+	if _, ok := f.batches.tasks[id]; !ok {
+		return db.Task{}, storage.ErrTaskNotFound
+	}
+	return db.Task{ID: id, BatchID: &f.batches.batch.ID, Status: f.batches.tasks[id]}, nil
+}
+
+func (f *fakeBatchTasks) CancelTask(ctx context.Context, id, cancelledBy, reason string) (db.Task, error) {
+	f.batches.tasks[id] = "cancelled"
+	return db.Task{ID: id, BatchID: &f.batches.batch.ID, Status: "cancelled"}, nil
+}
+
+func (f *fakeBatchTasks) DeleteTask(ctx context.Context, id string) error {
+	delete(f.batches.tasks, id)
+	return nil
+}
+
+func newBatchEnv(tasks map[string]string) (*TaskService, *fakeBatches) {
+	batches := &fakeBatches{
+		batch: db.Batch{
+			ID:         "batch-1",
+			CreatorID:  "user-1",
+			Name:       "import",
+			Total:      len(tasks),
+			OnComplete: &db.BatchHook{WebhookURL: "https://example.com/hook"},
+		},
+		tasks: tasks,
+	}
+	pdb := &fakeStorage{
+		batch:    batches,
+		task:     &fakeBatchTasks{batches: batches},
+		event:    nopEvents{},
+		workflow: &fakeWorkflow{},
+		webhook:  &fakeBatchDeliveries{batches: batches},
+	}
+	s := &TaskService{
+		storage:    pdb,
+		logger:     discardLogger(),
+		workerPool: NewWorkerPool(pdb, discardLogger(), config.WorkerConfig{}, &pushQueue{}, newHandlerRegistry()),
+	}
+	s.workerPool.OnFinished(s.batchTaskFinished)
+	return s, batches
+}
+
+func TestBatchWebhookQueuedWithCompletion(t *testing.T) {
+	s, batches := newBatchEnv(map[string]string{"t1": "completed", "t2": "processing"})
+
+	s.batchTaskFinished(&db.Task{ID: "t1", BatchID: &batches.batch.ID})
+	if len(batches.hooks) != 0 {
+		t.Fatalf("batch completed while a task is still processing")
+	}
+
+	batches.tasks["t2"] = "failed"
+	s.batchTaskFinished(&db.Task{ID: "t2", BatchID: &batches.batch.ID})
+	if len(batches.hooks) != 1 || batches.hooks[0] == nil {
+		t.Fatalf("hooks = %v, want one webhook delivery saved with the completion", batches.hooks)
+	}
+
+	hook := batches.hooks[0]
+	var payload BatchWebhookPayload
+	if err := json.Unmarshal(hook.Payload, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if hook.Event != BatchCompletedEvent || payload.Event != BatchCompletedEvent {
+		t.Fatalf("event = %s/%s, want %s", hook.Event, payload.Event, BatchCompletedEvent)
+	}
+	if payload.Data.ID != "batch-1" || payload.Data.Counts["completed"] != 1 || payload.Data.Counts["failed"] != 1 {
+		t.Fatalf("payload data = %+v", payload.Data)
+	}
+	if payload.Data.CompletedAt == nil || !payload.Data.CompletedAt.Equal(*batches.batch.CompletedAt) {
+		t.Fatalf("payload completed_at = %v, want %v", payload.Data.CompletedAt, batches.batch.CompletedAt)
+	}
+
+	// Hook bir marta
+	s.batchTaskFinished(&db.Task{ID: "t2", BatchID: &batches.batch.ID})
+	if len(batches.hooks) != 1 {
+		t.Fatalf("hook fired %d times, want 1", len(batches.hooks))
+	}
+}
+
+func TestDeleteLastPendingBatchTaskCompletesBatch(t *testing.T) {
+	s, batches := newBatchEnv(map[string]string{"t1": "completed", "t2": "pending"})
+
+	if err := s.DeleteTask(context.Background(), "t2", "user-1"); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if batches.batch.CompletedAt == nil || len(batches.hooks) != 1 {
+		t.Fatalf("batch not completed after its last pending task was deleted")
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"context"
+	"fmt"
+	"time"
+)
+
+// StateReportInterval - worker jarayoni o'z holatini bazaga yozish oralig'i.
+const StateReportInterval = 5 * time.Second
+
+// instanceTimeout - shuncha vaqt holat yubormagan worker jarayoni o'chgan hisoblanadi
+const instanceTimeout = 3 * StateReportInterval
+
+// dashboardRunningLimit - snapshotdagi bajarilayotgan tasklar soni chegarasi
+const dashboardRunningLimit = 100
+
+// reportStates - pool holatini muntazam bazaga yozish: API instancedagi dashboard
+// barcha worker jarayonlarini shu yozuvlardan ko'radi
+func (wp *WorkerPool) reportStates() {
+	ticker := time.NewTicker(StateReportInterval)
+	defer ticker.Stop()
+
+	for {
+		wp.reportState()
+
+		select {
+		case <-ticker.C:
+		case <-wp.stopping:
+			if err := wp.db.Worker().RemoveState(context.Background(), wp.instanceID); err != nil {
+				wp.logger.Error("Worker holatini o'chirishda xato", "error", err)
+			}
+			return
+		}
+	}
+}
+
+func (wp *WorkerPool) reportState() {
+	if err := wp.db.Worker().ReportState(context.Background(), wp.State()); err != nil {
+		wp.logger.Error("Worker holatini yozishda xato", "error", err)
+	}
+}
+
+// queueConsumers - har bir navbatdan task oladigan workerlar soni: alohida workerlar
+// va og'irligi 0 dan katta bo'lsa umumiy workerlar (faqat workerlari ishlayotgan instancelar)
+func queueConsumers(instances []db.PoolState) map[string]int {
+	consumers := make(map[string]int)
+	for _, inst := range instances {
+		if !inst.Running {
+			continue
+		}
+		for _, q := range inst.Queues {
+			n := q.Workers
+			if q.Weight > 0 {
+				n += inst.SharedWorkers
+			}
+			consumers[q.Name] += n
+		}
+	}
+	return consumers
+}
+
+// DashboardSnapshot - navbatlar hajmi, ishlayotgan worker jarayonlari va
+// bajarilayotgan tasklar (barcha instancelar bo'yicha)
+func (s *TaskService) DashboardSnapshot(ctx context.Context) (*db.DashboardSnapshot, error) {
+	depths, err := s.storage.Queue().Depths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	paused, err := s.storage.Queue().ListPaused(ctx)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := s.storage.Worker().ListStates(ctx, time.Now().Add(-instanceTimeout))
+	if err != nil {
+		return nil, err
+	}
+	running, err := s.storage.Task().ListTasks(ctx, map[string]interface{}{"status": "processing"}, dashboardRunningLimit, 0)
+	if err != nil {
+		return nil, fmt.Errorf("bajarilayotgan tasklarni olishda xato: %w", err)
+	}
+
+	// Tasklari yo'q, lekin to'xtatilgan navbatlar ham ko'rinishi kerak
+	index := make(map[string]int, len(depths))
+	for i := range depths {
+		index[depths[i].Queue] = i
+	}
+	for _, p := range paused {
+		if i, ok := index[p.Queue]; ok {
+			depths[i].Paused = true
+		} else {
+			depths = append(depths, db.QueueDepth{Queue: p.Queue, Paused: true})
+		}
+	}
+
+	consumers := queueConsumers(instances)
+	for i := range depths {
+		depths[i].Consumers = consumers[depths[i].Queue]
+	}
+
+	if depths == nil {
+		depths = []db.QueueDepth{}
+	}
+	if instances == nil {
+		instances = []db.PoolState{}
+	}
+	if running == nil {
+		running = []db.Task{}
+	}
+
+	return &db.DashboardSnapshot{
+		Queues:    depths,
+		Instances: instances,
+		Running:   running,
+		At:        time.Now(),
+	}, nil
+}
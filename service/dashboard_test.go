@@ -0,0 +1,34 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"testing"
+)
+
+func TestQueueConsumers(t *testing.T) {
+	consumers := queueConsumers([]db.PoolState{
+		{
+			Running:       true,
+			SharedWorkers: 4,
+			Queues: []db.QueueState{
+				{Name: "default", Weight: 3},
+				{Name: "emails", Workers: 2, Weight: 1},
+				{Name: "reports", Workers: 1},
+				{Name: "paused", Paused: true}, // Faqat to'xtatilgan, xizmat qilinmaydi
+			},
+		},
+		{
+			// Faqat API: workerlari ishlamaydi
+			Running:       false,
+			SharedWorkers: 10,
+			Queues:        []db.QueueState{{Name: "orphan", Workers: 5, Weight: 1}},
+		},
+	})
+
+	want := map[string]int{"default": 4, "emails": 6, "reports": 1, "paused": 0, "orphan": 0}
+	for queue, n := range want {
+		if consumers[queue] != n {
+			t.Errorf("consumers[%s] = %d, want %d", queue, consumers[queue], n)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// service/dead_letter_service.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ListDeadLetters - barcha qayta urinishlari tugagan tasklar ro'yxati
+func (s *TaskService) ListDeadLetters(ctx context.Context, filter storage.DeadLetterFilter, limit, offset int) ([]db.DeadLetter, error) {
+	list, err := s.storage.DeadLetter().ListDeadLetters(ctx, filter, limit, offset)
+	if err != nil {
+		s.logger.Error("Dead-letter ro'yxatini olishda xato", "error", err)
+		return nil, fmt.Errorf("dead-letter ro'yxatini olishda xato: %w", err)
+	}
+	return list, nil
+}
+
+// GetDeadLetter - dead-letter taskni xatolar tarixi bilan olish
+func (s *TaskService) GetDeadLetter(ctx context.Context, taskID string) (*db.DeadLetter, error) {
+	dl, err := s.storage.DeadLetter().GetDeadLetter(ctx, taskID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrDeadLetterNotFound) {
+			s.logger.Error("Dead-letter taskni olishda xato", "task_id", taskID, "error", err)
+		}
+		return nil, err
+	}
+	return &dl, nil
+}
+
+// RequeueDeadLetters - tanlangan dead-letter tasklarni retries=0 bilan qayta navbatga qo'yish.
+// Xatolar tarixi o'chirilmaydi: keyingi urinishlar unga qo'shiladi.
+func (s *TaskService) RequeueDeadLetters(ctx context.Context, filter storage.DeadLetterFilter) ([]db.Task, error) {
+	tasks, err := s.storage.DeadLetter().Requeue(ctx, filter)
+	if errors.Is(err, storage.ErrUniqueConflict) {
+		// Bitta so'rov: to'qnashuvda birorta ham task qayta navbatga qo'yilmaydi
+		return nil, fmt.Errorf("%w: hech bir task qayta navbatga qo'yilmadi", ErrDuplicateTask)
+	}
+	if err != nil {
+		s.logger.Error("Dead-letter tasklarni qayta navbatga qo'yishda xato", "error", err)
+		return nil, fmt.Errorf("qayta navbatga qo'yishda xato: %w", err)
+	}
+
+	for i := range tasks {
+		s.unskipDependants(ctx, tasks[i].ID)
+		s.workerPool.publishEvent(&tasks[i])
+		s.workerPool.Enqueue(&tasks[i])
+	}
+	s.logger.Info("Dead-letter tasklar qayta navbatga qo'yildi", "count", len(tasks))
+	return tasks, nil
+}
+
+// PurgeDeadLetters - tanlangan dead-letter tasklarni butunlay o'chirish
+func (s *TaskService) PurgeDeadLetters(ctx context.Context, filter storage.DeadLetterFilter) (int64, error) {
+	n, err := s.storage.DeadLetter().Purge(ctx, filter)
+	if err != nil {
+		s.logger.Error("Dead-letter tasklarni o'chirishda xato", "error", err)
+		return 0, fmt.Errorf("dead-letter tasklarni o'chirishda xato: %w", err)
+	}
+
+	s.logger.Info("Dead-letter tasklar o'chirildi", "count", n)
+	return n, nil
+}
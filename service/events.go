@@ -0,0 +1,162 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// maxEventError - hodisadagi xato matnining maksimal uzunligi (NOTIFY 8000 baytgacha)
+const maxEventError = 1000
+
+// IsFinalStatus - task bu holatdan o'z-o'zidan chiqmaydi
+func IsFinalStatus(status string) bool {
+	switch status {
+	case "completed", "failed", "cancelled", "skipped":
+		return true
+	}
+	return false
+}
+
+// NewTaskEvent - task joriy holatidan hodisa yaratish
+func NewTaskEvent(task *db.Task) db.TaskEvent {
+	event := db.TaskEvent{
+		TaskID:    task.ID,
+		CreatorID: task.CreatorID,
+		UserID:    task.UserID,
+		Type:      task.Type,
+		Queue:     task.Queue,
+		Status:    task.Status,
+		Retries:   task.Retries,
+		Worker:    task.ClaimedBy,
+		At:        time.Now(),
+	}
+	if task.LastError != nil {
+		msg := *task.LastError
+		if len(msg) > maxEventError {
+			// Ko'p baytli belgi o'rtasidan kesilmasin: JSON da u \ufffd ga aylanadi
+			cut := maxEventError
+			for cut > 0 && !utf8.RuneStart(msg[cut]) {
+				cut--
+			}
+			msg = msg[:cut]
+		}
+		event.Error = &msg
+	}
+	return event
+}
+
+// publishEvent - task holati o'zgarganini barcha API instancelarga (SSE) va
+// foydalanuvchi webhooklariga e'lon qilish. Yuborilmasa ham task ishlashda davom etadi.
+func (wp *WorkerPool) publishEvent(task *db.Task) {
+	event := NewTaskEvent(task)
+	if err := wp.db.TaskEvent().Publish(context.Background(), event); err != nil {
+		wp.logger.Error("Task hodisasini yuborishda xato", "task_id", task.ID, "error", err)
+	}
+	enqueueWebhooks(wp.db, wp.logger, event)
+}
+
+// TaskEventHub - bazadan kelgan task hodisalarini shu instancedagi obunachilarga
+// (SSE ulanishlariga) tarqatadi. Sekin obunachi boshqalarni to'xtatmasligi uchun
+// uning buferi to'lsa hodisa unga yetkazilmaydi.
+type TaskEventHub struct {
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[*eventSub]struct{}
+	closed bool
+}
+
+// eventSub - bitta obuna: taskID bo'lsa faqat shu task, aks holda foydalanuvchi tasklari
+type eventSub struct {
+	taskID string
+	userID string
+	all    bool // Barcha tasklar (admin)
+	ch     chan db.TaskEvent
+}
+
+// NewTaskEventHub - yangi TaskEventHub yaratish
+func NewTaskEventHub(logger *slog.Logger) *TaskEventHub {
+	return &TaskEventHub{
+		logger: logger,
+		subs:   make(map[*eventSub]struct{}),
+	}
+}
+
+// Start - hodisalar kanalini o'qib obunachilarga tarqatishni boshlash.
+// Kanal yopilganda barcha obunalar ham yopiladi (SSE ulanishlari tugaydi).
+func (h *TaskEventHub) Start(events <-chan db.TaskEvent) {
+	go func() {
+		for event := range events {
+			h.broadcast(event)
+		}
+		h.close()
+	}()
+}
+
+func (h *TaskEventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for sub := range h.subs {
+		close(sub.ch)
+		delete(h.subs, sub)
+	}
+}
+
+func (h *TaskEventHub) broadcast(event db.TaskEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			h.logger.Warn("Obunachi hodisalarni o'qimayapti, hodisa tashlab yuborildi", "task_id", event.TaskID)
+		}
+	}
+}
+
+func (s *eventSub) matches(event db.TaskEvent) bool {
+	if s.taskID != "" {
+		return event.TaskID == s.taskID
+	}
+	return s.all || event.CreatorID == s.userID || event.UserID == s.userID
+}
+
+// SubscribeTask - bitta task hodisalariga obuna. Qaytgan funksiya obunani bekor qiladi.
+func (h *TaskEventHub) SubscribeTask(taskID string) (<-chan db.TaskEvent, func()) {
+	return h.subscribe(&eventSub{taskID: taskID})
+}
+
+// SubscribeUser - foydalanuvchi yaratgan yoki unga biriktirilgan tasklar hodisalariga
+// obuna; all bo'lsa barcha tasklar
+func (h *TaskEventHub) SubscribeUser(userID string, all bool) (<-chan db.TaskEvent, func()) {
+	return h.subscribe(&eventSub{userID: userID, all: all})
+}
+
+func (h *TaskEventHub) subscribe(sub *eventSub) (<-chan db.TaskEvent, func()) {
+	sub.ch = make(chan db.TaskEvent, 64)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	h.subs[sub] = struct{}{}
+
+	return sub.ch, func() {
+		h.mu.Lock()
+		delete(h.subs, sub)
+		h.mu.Unlock()
+	}
+}
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNewTaskEventTruncatesOnRuneBoundary(t *testing.T) {
+	// "ў" 2 bayt: maxEventError chegarasi belgi o'rtasiga to'g'ri keladi
+	msg := "x" + strings.Repeat("ў", maxEventError)
+	event := NewTaskEvent(&db.Task{ID: "task-1", Status: "failed", LastError: &msg})
+
+	if event.Error == nil {
+		t.Fatalf("event error is nil")
+	}
+	got := *event.Error
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncated error is not valid UTF-8")
+	}
+	if len(got) != maxEventError-1 || !strings.HasPrefix(msg, got) {
+		t.Fatalf("truncated to %d bytes, want %d", len(got), maxEventError-1)
+	}
+}
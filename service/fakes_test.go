@@ -0,0 +1,32 @@
+package service
+
+import (
+	"asynchronous/storage"
+	"io"
+	"log/slog"
+)
+
+// fakeStorage - testlar uchun storage: faqat kerakli sub-storagelar to'ldiriladi,
+// qolganlariga murojaat panic beradi
+type fakeStorage struct {
+	storage.IStorage
+	task       storage.ITaskStorage
+	attempt    storage.ITaskAttemptStorage
+	event      storage.ITaskEventStorage
+	workflow   storage.IWorkflowStorage
+	batch      storage.IBatchStorage
+	deadLetter storage.IDeadLetterStorage
+	webhook    storage.IWebhookStorage
+}
+
+func (f *fakeStorage) Task() storage.ITaskStorage               { return f.task }
+func (f *fakeStorage) TaskAttempt() storage.ITaskAttemptStorage { return f.attempt }
+func (f *fakeStorage) TaskEvent() storage.ITaskEventStorage     { return f.event }
+func (f *fakeStorage) Workflow() storage.IWorkflowStorage       { return f.workflow }
+func (f *fakeStorage) Batch() storage.IBatchStorage             { return f.batch }
+func (f *fakeStorage) DeadLetter() storage.IDeadLetterStorage   { return f.deadLetter }
+func (f *fakeStorage) Webhook() storage.IWebhookStorage         { return f.webhook }
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// typeLimiter - task turlari bo'yicha bir vaqtda bajarilish va tezlik cheklovlari.
+// Dispatcher cheklovga yetgan turlarni navbatdan olmaydi; tezlik token bucket
+// orqali hisoblanadi va shu jarayon doirasida amal qiladi. max_concurrency bu yerda
+// faqat shu jarayon uchun oldindan filtr: barcha instancelar bo'yicha u ClaimTask da
+// bazada tekshiriladi.
+type typeLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]*typeBucket
+	running map[string]int
+}
+
+// typeBucket - bitta tur cheklovi va uning tokenlari
+type typeBucket struct {
+	limit  db.TaskTypeLimit
+	tokens float64
+	last   time.Time // Tokenlar oxirgi marta to'ldirilgan vaqt
+}
+
+func newTypeLimiter() *typeLimiter {
+	return &typeLimiter{
+		limits:  make(map[string]*typeBucket),
+		running: make(map[string]int),
+	}
+}
+
+// burst - bucket sig'imi: ko'rsatilmagan bo'lsa bir sekundlik tokenlar (kamida 1)
+func (b *typeBucket) burst() float64 {
+	if b.limit.Burst > 0 {
+		return float64(b.limit.Burst)
+	}
+	return math.Max(1, math.Ceil(b.limit.RatePerSecond))
+}
+
+// refill - o'tgan vaqt uchun tokenlarni qo'shish
+func (b *typeBucket) refill(now time.Time) {
+	if b.limit.RatePerSecond <= 0 {
+		return
+	}
+	b.tokens = math.Min(b.burst(), b.tokens+now.Sub(b.last).Seconds()*b.limit.RatePerSecond)
+	b.last = now
+}
+
+// set - cheklovni qo'shish yoki almashtirish. Tur avval ham cheklangan bo'lsa
+// to'plangan tokenlar saqlanadi (yangi sig'imdan oshmagan holda).
+func (l *typeLimiter) set(limit db.TaskTypeLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.setLocked(limit, time.Now())
+}
+
+func (l *typeLimiter) setLocked(limit db.TaskTypeLimit, now time.Time) {
+	b, ok := l.limits[limit.Type]
+	if !ok {
+		b = &typeBucket{}
+		l.limits[limit.Type] = b
+	}
+
+	// Avval tezlik cheklovi bo'lmagan bo'lsa bucket to'la holda boshlanadi
+	fresh := !ok || b.limit.RatePerSecond <= 0
+	b.refill(now)
+	b.limit = limit
+	b.last = now
+	if fresh {
+		b.tokens = b.burst()
+	}
+	b.tokens = math.Min(b.tokens, b.burst())
+}
+
+// replace - barcha cheklovlarni bazadagi ro'yxat bilan almashtirish
+func (l *typeLimiter) replace(limits []db.TaskTypeLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool, len(limits))
+	for _, limit := range limits {
+		seen[limit.Type] = true
+		l.setLocked(limit, now)
+	}
+	for t := range l.limits {
+		if !seen[t] {
+			delete(l.limits, t)
+		}
+	}
+}
+
+// remove - tur cheklovini olib tashlash
+func (l *typeLimiter) remove(taskType string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.limits, taskType)
+}
+
+// blocked - hozir navbatdan olib bo'lmaydigan turlar va eng yaqin token
+// paydo bo'lishigacha qolgan vaqt (tezlik bo'yicha bloklangan tur bo'lmasa 0)
+func (l *typeLimiter) blocked() ([]string, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	var types []string
+	var retryIn time.Duration
+	for t, b := range l.limits {
+		if b.limit.MaxConcurrency > 0 && l.running[t] >= b.limit.MaxConcurrency {
+			types = append(types, t)
+			continue
+		}
+		b.refill(now)
+		if b.limit.RatePerSecond > 0 && b.tokens < 1 {
+			types = append(types, t)
+			wait := time.Duration((1 - b.tokens) / b.limit.RatePerSecond * float64(time.Second))
+			if retryIn == 0 || wait < retryIn {
+				retryIn = wait
+			}
+		}
+	}
+	return types, retryIn
+}
+
+// acquire - olingan task uchun joy va token band qilish. Bir nechta worker bir vaqtda
+// bir xil turni olgan bo'lsa cheklovdan oshganlari false oladi va taskni qaytaradi.
+func (l *typeLimiter) acquire(taskType string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.limits[taskType]; ok {
+		if b.limit.MaxConcurrency > 0 && l.running[taskType] >= b.limit.MaxConcurrency {
+			return false
+		}
+		if b.limit.RatePerSecond > 0 {
+			b.refill(time.Now())
+			if b.tokens < 1 {
+				return false
+			}
+			b.tokens--
+		}
+	}
+	l.running[taskType]++
+	return true
+}
+
+// release - task bajarilib bo'lgach joyni bo'shatish. Tur cheklangan bo'lsa true qaytaradi.
+func (l *typeLimiter) release(taskType string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.running[taskType] <= 1 {
+		delete(l.running, taskType)
+	} else {
+		l.running[taskType]--
+	}
+	_, limited := l.limits[taskType]
+	return limited
+}
+
+// status - barcha cheklovlar va ularning joriy holati (tur bo'yicha saralangan)
+func (l *typeLimiter) status() []db.TaskTypeLimitStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	list := make([]db.TaskTypeLimitStatus, 0, len(l.limits))
+	for t, b := range l.limits {
+		b.refill(now)
+		list = append(list, db.TaskTypeLimitStatus{
+			TaskTypeLimit: b.limit,
+			Running:       l.running[t],
+			Tokens:        b.tokens,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Type < list[j].Type })
+	return list
+}
+
+// loadLimits - cheklovlarni bazadan yuklash. Xato bo'lsa avvalgi cheklovlar qoladi.
+func (wp *WorkerPool) loadLimits() {
+	limits, err := wp.db.TaskTypeLimit().ListLimits(context.Background())
+	if err != nil {
+		wp.logger.Error("Task turlari cheklovlarini yuklashda xato", "error", err)
+		return
+	}
+	wp.limiter.replace(limits)
+}
+
+// reloadControls - boshqa instancelarda o'zgartirilgan cheklovlar va to'xtatilgan
+// navbatlarni muntazam olish, admin buyruqlarini xabar kelganda darhol bajarish
+func (wp *WorkerPool) reloadControls() {
+	ticker := time.NewTicker(wp.limitsReload)
+	defer ticker.Stop()
+
+	commands := wp.commands
+	for {
+		select {
+		case <-ticker.C:
+			wp.loadLimits()
+			wp.loadPausedQueues()
+			wp.applyCommands()
+		case _, ok := <-commands:
+			if !ok {
+				commands = nil
+				continue
+			}
+			wp.applyCommands()
+		case <-wp.stopping:
+			return
+		}
+	}
+}
+
+// SetTypeLimit - shu jarayondagi cheklovni darhol o'zgartirish
+func (wp *WorkerPool) SetTypeLimit(limit db.TaskTypeLimit) {
+	wp.limiter.set(limit)
+	wp.Notify()
+}
+
+// RemoveTypeLimit - shu jarayondagi cheklovni darhol olib tashlash
+func (wp *WorkerPool) RemoveTypeLimit(taskType string) {
+	wp.limiter.remove(taskType)
+	wp.Notify()
+}
+
+// TypeLimits - cheklovlar va ularning shu jarayondagi holati
+func (wp *WorkerPool) TypeLimits() []db.TaskTypeLimitStatus {
+	return wp.limiter.status()
+}
+
+// ListTypeLimits - task turlari cheklovlari va ularning joriy holati. Bajarilayotgan
+// tasklar barcha instancelar bo'yicha bazadan sanaladi; tokenlar shu jarayonniki
+// (API jarayonida tezlik cheklovi qo'llanmaydi, shuning uchun bucket sig'imi ko'rsatiladi).
+func (s *TaskService) ListTypeLimits(ctx context.Context) ([]db.TaskTypeLimitStatus, error) {
+	limits, err := s.storage.TaskTypeLimit().ListLimits(ctx)
+	if err != nil {
+		s.logger.Error("Cheklovlarni olishda xato", "error", err)
+		return nil, err
+	}
+	running, err := s.storage.TaskTypeLimit().RunningCounts(ctx)
+	if err != nil {
+		s.logger.Error("Bajarilayotgan tasklarni sanashda xato", "error", err)
+		return nil, err
+	}
+
+	local := make(map[string]db.TaskTypeLimitStatus)
+	for _, st := range s.workerPool.TypeLimits() {
+		local[st.Type] = st
+	}
+
+	list := make([]db.TaskTypeLimitStatus, 0, len(limits))
+	for _, limit := range limits {
+		st := db.TaskTypeLimitStatus{TaskTypeLimit: limit, Running: running[limit.Type]}
+		if limit.RatePerSecond > 0 {
+			st.Tokens = (&typeBucket{limit: limit}).burst()
+			if l, ok := local[limit.Type]; ok {
+				st.Tokens = l.Tokens
+			}
+		}
+		list = append(list, st)
+	}
+	return list, nil
+}
+
+// SetTypeLimit - task turi cheklovini saqlash va darhol qo'llash.
+// Boshqa instancelar uni keyingi qayta yuklashda oladi.
+func (s *TaskService) SetTypeLimit(ctx context.Context, limit db.TaskTypeLimit) (*db.TaskTypeLimit, error) {
+	if limit.Type == "" {
+		return nil, fmt.Errorf("%w: type bo'sh bo'lishi mumkin emas", ErrInvalidTask)
+	}
+	if limit.MaxConcurrency < 0 || limit.RatePerSecond < 0 || limit.Burst < 0 {
+		return nil, fmt.Errorf("%w: cheklov qiymatlari manfiy bo'lishi mumkin emas", ErrInvalidTask)
+	}
+
+	saved, err := s.storage.TaskTypeLimit().UpsertLimit(ctx, limit)
+	if err != nil {
+		s.logger.Error("Cheklovni saqlashda xato", "type", limit.Type, "error", err)
+		return nil, err
+	}
+	s.workerPool.SetTypeLimit(saved)
+
+	s.logger.Info("Task turi cheklovi o'zgartirildi",
+		"type", saved.Type,
+		"max_concurrency", saved.MaxConcurrency,
+		"rate_per_second", saved.RatePerSecond,
+		"burst", saved.Burst,
+	)
+	return &saved, nil
+}
+
+// DeleteTypeLimit - task turi cheklovini olib tashlash
+func (s *TaskService) DeleteTypeLimit(ctx context.Context, taskType string) error {
+	if err := s.storage.TaskTypeLimit().DeleteLimit(ctx, taskType); err != nil {
+		if !errors.Is(err, storage.ErrTaskTypeLimitNotFound) {
+			s.logger.Error("Cheklovni o'chirishda xato", "type", taskType, "error", err)
+		}
+		return err
+	}
+	s.workerPool.RemoveTypeLimit(taskType)
+
+	s.logger.Info("Task turi cheklovi olib tashlandi", "type", taskType)
+	return nil
+}
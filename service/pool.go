@@ -0,0 +1,400 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidScale - workerlar sonini bunday o'zgartirib bo'lmaydi
+var ErrInvalidScale = errors.New("workerlar sonini o'zgartirib bo'lmaydi")
+
+// maxWorkersPerGroup - bitta guruhdagi (umumiy yoki bitta navbat) workerlar chegarasi
+const maxWorkersPerGroup = 1000
+
+// workerInfo - ishlayotgan worker goroutinasi haqida ma'lumot
+type workerInfo struct {
+	id       int
+	queue    string        // Alohida worker navbati; umumiy worker uchun bo'sh
+	quit     chan struct{} // Yopilsa worker joriy taskni tugatib chiqadi
+	stopping bool
+	task     *db.Task  // Hozir bajarilayotgan task (nil - bo'sh)
+	since    time.Time // Joriy holatga o'tgan vaqt
+}
+
+// spawnLocked - yangi worker goroutinasini ishga tushirish. wp.mu ushlangan bo'lishi kerak.
+// Pool to'xtatilayotgan bo'lsa worker qo'shilmaydi (wg.Wait bilan poyga bo'lmasligi uchun).
+func (wp *WorkerPool) spawnLocked(queue string) bool {
+	select {
+	case <-wp.stopping:
+		return false
+	default:
+	}
+
+	wp.lastWorkerID++
+	w := &workerInfo{
+		id:    wp.lastWorkerID,
+		queue: queue,
+		quit:  make(chan struct{}),
+		since: time.Now(),
+	}
+	wp.workers[w.id] = w
+
+	wp.wg.Add(1)
+	go wp.worker(w)
+	return true
+}
+
+// removeWorker - chiqib ketgan workerni ro'yxatdan olib tashlash
+func (wp *WorkerPool) removeWorker(w *workerInfo) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	delete(wp.workers, w.id)
+}
+
+// setWorkerTask - worker holatini yangilash: task nil bo'lsa worker bo'sh
+func (wp *WorkerPool) setWorkerTask(w *workerInfo, task *db.Task) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	w.task = task
+	w.since = time.Now()
+}
+
+// workerQueues - worker navbatlari ustuvorlik tartibida, to'xtatilganlari olib tashlangan
+func (wp *WorkerPool) workerQueues(w *workerInfo) []string {
+	var queues []string
+	if w.queue != "" {
+		queues = []string{w.queue}
+	} else {
+		queues = wp.weighted.order()
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	active := queues[:0]
+	for _, q := range queues {
+		if !wp.paused[q] {
+			active = append(active, q)
+		}
+	}
+	return active
+}
+
+// Scale - workerlar sonini o'zgartirish. queue bo'sh bo'lsa umumiy workerlar,
+// aks holda shu navbatning alohida workerlari. Kamaytirilganda ortiqcha workerlar
+// (avval bo'shlari) joriy taskni tugatib chiqadi. Yangi soni qaytaradi.
+func (wp *WorkerPool) Scale(queue string, n int) (int, error) {
+	if n < 0 || n > maxWorkersPerGroup {
+		return 0, fmt.Errorf("%w: workerlar soni 0 dan %d gacha bo'lishi kerak", ErrInvalidScale, maxWorkersPerGroup)
+	}
+	if queue == "" && wp.weighted.empty() {
+		return 0, fmt.Errorf("%w: umumiy workerlar uchun og'irligi 0 dan katta navbat yo'q", ErrInvalidScale)
+	}
+	if queue != "" {
+		if err := validateQueue(&queue); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidScale, err)
+		}
+	}
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if !wp.started {
+		return 0, fmt.Errorf("%w: bu instanceda workerlar ishga tushirilmagan (ular cmd/worker da)", ErrInvalidScale)
+	}
+
+	// Shu guruhdagi to'xtatilmayotgan workerlar; bo'shlari oldinda, yangilari oxirida
+	var group []*workerInfo
+	for _, w := range wp.workers {
+		if w.queue == queue && !w.stopping {
+			group = append(group, w)
+		}
+	}
+	sort.Slice(group, func(i, j int) bool {
+		if (group[i].task == nil) != (group[j].task == nil) {
+			return group[i].task == nil
+		}
+		return group[i].id > group[j].id
+	})
+
+	for len(group) > n {
+		w := group[0]
+		group = group[1:]
+		w.stopping = true
+		close(w.quit)
+	}
+	for i := len(group); i < n; i++ {
+		if !wp.spawnLocked(queue) {
+			return 0, fmt.Errorf("%w: pool to'xtatilmoqda", ErrInvalidScale)
+		}
+	}
+
+	// Hisobot uchun sozlamani yangilash
+	if queue == "" {
+		wp.workerCount = n
+	} else {
+		found := false
+		for i := range wp.queues {
+			if wp.queues[i].Name == queue {
+				wp.queues[i].Workers = n
+				found = true
+			}
+		}
+		if !found {
+			wp.queues = append(wp.queues, config.QueueConfig{Name: queue, Workers: n})
+		}
+	}
+
+	wp.logger.Info("Workerlar soni o'zgartirildi", "queue", queue, "workers", n)
+	return n, nil
+}
+
+// loadPausedQueues - to'xtatilgan navbatlarni bazadan yuklash
+func (wp *WorkerPool) loadPausedQueues() {
+	list, err := wp.db.Queue().ListPaused(context.Background())
+	if err != nil {
+		wp.logger.Error("To'xtatilgan navbatlarni yuklashda xato", "error", err)
+		return
+	}
+
+	paused := make(map[string]bool, len(list))
+	for _, q := range list {
+		paused[q.Queue] = true
+	}
+
+	wp.mu.Lock()
+	wp.paused = paused
+	wp.mu.Unlock()
+}
+
+// WatchCommands - signals dan xabar kelganda (NOTIFY) yangi buyruqlarni darhol o'qish.
+// Xabarlar kelmasa ham buyruqlar qayta yuklash oralig'ida o'qiladi. Start dan oldin chaqirilishi kerak.
+func (wp *WorkerPool) WatchCommands(signals <-chan struct{}) {
+	wp.commands = signals
+}
+
+// applyCommands - shu jarayonga yuborilgan yangi buyruqlarni bajarish. Birinchi chaqiruvda
+// faqat oxirgi buyruq eslab qolinadi: ishga tushishdan oldingi buyruqlar boshqa jarayonlar uchun edi.
+// Faqat Start va reloadControls goroutinasidan chaqiriladi.
+func (wp *WorkerPool) applyCommands() {
+	ctx := context.Background()
+	if !wp.commandsLoaded {
+		last, err := wp.db.Worker().LastCommandID(ctx)
+		if err != nil {
+			wp.logger.Error("Oxirgi buyruqni olishda xato", "error", err)
+			return
+		}
+		wp.lastCommand, wp.commandsLoaded = last, true
+		return
+	}
+
+	cmds, err := wp.db.Worker().ListCommands(ctx, wp.instanceID, wp.lastCommand)
+	if err != nil {
+		wp.logger.Error("Buyruqlarni olishda xato", "error", err)
+		return
+	}
+	if len(cmds) == 0 {
+		return
+	}
+
+	reloadPaused := false
+	for _, cmd := range cmds {
+		wp.lastCommand = cmd.ID
+		switch cmd.Action {
+		case db.WorkerCommandScale:
+			if cmd.Workers == nil {
+				continue
+			}
+			if _, err := wp.Scale(cmd.Queue, *cmd.Workers); err != nil {
+				wp.logger.Error("Buyruqni bajarib bo'lmadi", "command_id", cmd.ID, "action", cmd.Action, "error", err)
+			}
+		case db.WorkerCommandPause, db.WorkerCommandResume:
+			reloadPaused = true
+		}
+	}
+	if reloadPaused {
+		// paused_queues jadvali asosiy manba: buyruq faqat uni darhol qayta o'qish uchun
+		wp.loadPausedQueues()
+		wp.Notify()
+	}
+
+	// Admin o'zgarishni keyingi hisobotni kutmasdan ko'rsin
+	wp.reportState()
+}
+
+// State - workerlar va navbatlarning joriy holati
+func (wp *WorkerPool) State() db.PoolState {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	state := db.PoolState{
+		InstanceID:    wp.instanceID,
+		Running:       wp.started,
+		SharedWorkers: wp.workerCount,
+		Queues:        make([]db.QueueState, 0, len(wp.queues)),
+		Workers:       make([]db.WorkerState, 0, len(wp.workers)),
+	}
+
+	seen := make(map[string]bool, len(wp.queues))
+	for _, q := range wp.queues {
+		seen[q.Name] = true
+		state.Queues = append(state.Queues, db.QueueState{
+			Name:    q.Name,
+			Workers: q.Workers,
+			Weight:  q.Weight,
+			Paused:  wp.paused[q.Name],
+		})
+	}
+	// Shu jarayon xizmat qilmaydigan, lekin to'xtatilgan navbatlar
+	for q := range wp.paused {
+		if !seen[q] {
+			state.Queues = append(state.Queues, db.QueueState{Name: q, Paused: true})
+		}
+	}
+
+	for _, w := range wp.workers {
+		ws := db.WorkerState{
+			ID:    w.id,
+			Name:  wp.workerName(w.id),
+			Queue: w.queue,
+			State: db.WorkerIdle,
+			Since: w.since,
+		}
+		if w.task != nil {
+			ws.State = db.WorkerBusy
+			ws.TaskID = w.task.ID
+			ws.TaskType = w.task.Type
+		}
+		if w.stopping {
+			ws.State = db.WorkerStopping
+		}
+		state.Workers = append(state.Workers, ws)
+	}
+	sort.Slice(state.Workers, func(i, j int) bool { return state.Workers[i].ID < state.Workers[j].ID })
+
+	return state
+}
+
+// PoolStates - ishlayotgan barcha worker jarayonlarining holati (ular yozgan hisobotlardan)
+func (s *TaskService) PoolStates(ctx context.Context) ([]db.PoolState, error) {
+	states, err := s.storage.Worker().ListStates(ctx, time.Now().Add(-instanceTimeout))
+	if err != nil {
+		s.logger.Error("Worker holatlarini olishda xato", "error", err)
+		return nil, err
+	}
+	if states == nil {
+		states = []db.PoolState{}
+	}
+	return states, nil
+}
+
+// WatchCommands - worker jarayonida admin buyruqlari haqidagi xabarlarni kuzatish
+func (s *TaskService) WatchCommands(signals <-chan struct{}) {
+	s.workerPool.WatchCommands(signals)
+}
+
+// ScaleWorkers - worker jarayonlariga workerlar sonini o'zgartirish buyrug'ini yuborish.
+// instanceID bo'sh bo'lsa buyruq barcha ishlayotgan jarayonlarga, aks holda faqat shu
+// jarayonga tegishli. Har bir jarayon o'z workerlarini n taga keltiradi.
+func (s *TaskService) ScaleWorkers(ctx context.Context, instanceID, queue string, n int, createdBy string) (*db.WorkerCommand, error) {
+	if n < 0 || n > maxWorkersPerGroup {
+		return nil, fmt.Errorf("%w: workerlar soni 0 dan %d gacha bo'lishi kerak", ErrInvalidScale, maxWorkersPerGroup)
+	}
+	if queue != "" {
+		if err := validateQueue(&queue); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidScale, err)
+		}
+	}
+
+	states, err := s.PoolStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, st := range states {
+		if instanceID == "" || st.InstanceID == instanceID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		if instanceID != "" {
+			return nil, fmt.Errorf("%w: %s worker jarayoni ishlamayapti", ErrInvalidScale, instanceID)
+		}
+		return nil, fmt.Errorf("%w: ishlayotgan worker jarayoni yo'q", ErrInvalidScale)
+	}
+
+	cmd := db.WorkerCommand{
+		Action:  db.WorkerCommandScale,
+		Queue:   queue,
+		Workers: &n,
+	}
+	if instanceID != "" {
+		cmd.InstanceID = &instanceID
+	}
+	if createdBy != "" {
+		cmd.CreatedBy = &createdBy
+	}
+
+	sent, err := s.storage.Worker().SendCommand(ctx, cmd)
+	if err != nil {
+		s.logger.Error("Workerlar sonini o'zgartirish buyrug'ini yuborishda xato", "queue", queue, "error", err)
+		return nil, err
+	}
+
+	s.logger.Info("Workerlar sonini o'zgartirish buyrug'i yuborildi",
+		"command_id", sent.ID,
+		"instance_id", instanceID,
+		"queue", queue,
+		"workers", n,
+	)
+	return &sent, nil
+}
+
+// notifyWorkers - navbat holati o'zgargani haqida worker jarayonlariga buyruq yuborish.
+// Yuborilmasa ham jarayonlar o'zgarishni qayta yuklash oralig'ida oladi.
+func (s *TaskService) notifyWorkers(ctx context.Context, action, queue, createdBy string) {
+	cmd := db.WorkerCommand{Action: action, Queue: queue}
+	if createdBy != "" {
+		cmd.CreatedBy = &createdBy
+	}
+	if _, err := s.storage.Worker().SendCommand(ctx, cmd); err != nil {
+		s.logger.Error("Worker jarayonlariga buyruq yuborishda xato", "action", action, "queue", queue, "error", err)
+	}
+}
+
+// PauseQueue - navbatdan yangi task olishni to'xtatish; bajarilayotgan tasklar tugaydi.
+// Worker jarayonlari buni buyruq orqali darhol oladi.
+func (s *TaskService) PauseQueue(ctx context.Context, queue, pausedBy string) error {
+	if err := validateQueue(&queue); err != nil {
+		return err
+	}
+	if err := s.storage.Queue().Pause(ctx, queue, pausedBy); err != nil {
+		s.logger.Error("Navbatni to'xtatishda xato", "queue", queue, "error", err)
+		return err
+	}
+	s.notifyWorkers(ctx, db.WorkerCommandPause, queue, pausedBy)
+
+	s.logger.Info("Navbat to'xtatildi", "queue", queue, "paused_by", pausedBy)
+	return nil
+}
+
+// ResumeQueue - to'xtatilgan navbatdan task olishni davom ettirish
+func (s *TaskService) ResumeQueue(ctx context.Context, queue string) error {
+	if err := validateQueue(&queue); err != nil {
+		return err
+	}
+	if _, err := s.storage.Queue().Resume(ctx, queue); err != nil {
+		s.logger.Error("Navbatni davom ettirishda xato", "queue", queue, "error", err)
+		return err
+	}
+	s.notifyWorkers(ctx, db.WorkerCommandResume, queue, "")
+
+	s.logger.Info("Navbat davom ettirildi", "queue", queue)
+	return nil
+}
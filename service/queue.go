@@ -0,0 +1,78 @@
+package service
+
+import (
+	"asynchronous/config"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+)
+
+// DefaultQueue - navbat ko'rsatilmagan tasklar tushadigan navbat
+const DefaultQueue = "default"
+
+var queueNameRe = regexp.MustCompile(`^[a-zA-Z0-9_.-]{1,100}$`)
+
+// validateQueue - navbat nomini tekshirish; bo'sh bo'lsa DefaultQueue qo'yiladi.
+// Navbatga xizmat qiluvchi worker borligi tekshirilmaydi: API va worker jarayonlari
+// alohida sozlanadi va workerlar keyinroq ishga tushishi mumkin. Xizmat qilinmayotgan
+// navbat dashboard snapshotida consumers=0 bilan ko'rinadi.
+func validateQueue(queue *string) error {
+	if *queue == "" {
+		*queue = DefaultQueue
+	}
+	if !queueNameRe.MatchString(*queue) {
+		return fmt.Errorf("%w: queue faqat harf, raqam, '_', '-' va '.' dan iborat bo'lishi kerak (100 belgigacha)", ErrInvalidTask)
+	}
+	return nil
+}
+
+// weightedQueues - umumiy workerlar uchun navbatlar tartibi. Har bir so'rovda
+// og'irlik bo'yicha tasodifiy tartib beriladi: og'irligi katta navbat ko'proq
+// birinchi bo'ladi, lekin og'irligi kichik navbat ham och qolmaydi.
+type weightedQueues struct {
+	names   []string
+	weights []int
+	total   int
+}
+
+// newWeightedQueues - og'irligi 0 dan katta navbatlardan tanlovchi
+func newWeightedQueues(queues []config.QueueConfig) *weightedQueues {
+	wq := &weightedQueues{}
+	for _, q := range queues {
+		if q.Weight <= 0 {
+			continue
+		}
+		wq.names = append(wq.names, q.Name)
+		wq.weights = append(wq.weights, q.Weight)
+		wq.total += q.Weight
+	}
+	return wq
+}
+
+// empty - umumiy workerlar xizmat qiladigan navbat yo'q
+func (wq *weightedQueues) empty() bool {
+	return len(wq.names) == 0
+}
+
+// order - navbatlarni og'irlik bo'yicha qaytarilmaydigan tanlash bilan tartiblash
+func (wq *weightedQueues) order() []string {
+	names := append([]string(nil), wq.names...)
+	weights := append([]int(nil), wq.weights...)
+	total := wq.total
+
+	order := make([]string, 0, len(names))
+	for len(names) > 0 {
+		r := rand.IntN(total)
+		i := 0
+		for r >= weights[i] {
+			r -= weights[i]
+			i++
+		}
+
+		order = append(order, names[i])
+		total -= weights[i]
+		names = append(names[:i], names[i+1:]...)
+		weights = append(weights[:i], weights[i+1:]...)
+	}
+	return order
+}
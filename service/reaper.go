@@ -0,0 +1,106 @@
+// service/reaper.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Reaper - lease muddati o'tgan tasklarni (worker qulab tushgan yoki aloqa uzilgan)
+// davriy ravishda qaytaradi: qayta urinish qolgan bo'lsa "pending", aks holda "failed"
+type Reaper struct {
+	db       storage.IStorage
+	logger   *slog.Logger
+	interval time.Duration
+	publish  func(task *db.Task) // Holat o'zgarishini e'lon qilish (SSE, webhook)
+	requeue  func(task *db.Task) // Qaytarilgan pending taskni navbatga uzatish
+	finished func(task *db.Task) // Urinishlari tugab failed bo'lgan task uchun
+	stop     chan struct{}
+}
+
+// NewReaper - yangi Reaper yaratish
+func NewReaper(
+	db storage.IStorage,
+	logger *slog.Logger,
+	interval time.Duration,
+	publish func(task *db.Task),
+	requeue func(task *db.Task),
+	finished func(task *db.Task),
+) *Reaper {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	return &Reaper{
+		db:       db,
+		logger:   logger,
+		interval: interval,
+		publish:  publish,
+		requeue:  requeue,
+		finished: finished,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start - reaperni ishga tushirish
+func (r *Reaper) Start() {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.reap()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop - reaperni to'xtatish
+func (r *Reaper) Stop() {
+	close(r.stop)
+}
+
+// nextRetryAt - retries marta muvaffaqiyatsiz bo'lgan taskning keyingi urinish vaqti
+// (worker xatosidagi kabi task siyosati bo'yicha)
+func nextRetryAt(task db.Task) time.Time {
+	return time.Now().Add(retryDelay(task.RetryPolicy, task.Retries))
+}
+
+// reap - muddati o'tgan leaselarni bir marta tekshirish
+func (r *Reaper) reap() {
+	tasks, err := r.db.Task().ReapExpiredLeases(context.Background(), nextRetryAt)
+	if err != nil {
+		r.logger.Error("Muddati o'tgan tasklarni qaytarishda xato", "error", err)
+		return
+	}
+
+	ids := make([]string, 0, len(tasks))
+	for i := range tasks {
+		ids = append(ids, tasks[i].ID)
+	}
+	if err := r.db.TaskAttempt().AbandonAttempts(context.Background(), ids); err != nil {
+		r.logger.Error("Yakunlanmagan urinishlarni yopishda xato", "error", err)
+	}
+
+	for i := range tasks {
+		r.logger.Warn("Lease muddati o'tgan task qaytarildi",
+			"task_id", tasks[i].ID,
+			"status", tasks[i].Status,
+			"retries", tasks[i].Retries,
+			"next_retry_at", tasks[i].NextRetryAt,
+		)
+		r.publish(&tasks[i])
+		if tasks[i].Status == "pending" {
+			r.requeue(&tasks[i])
+		} else {
+			r.finished(&tasks[i])
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// service/recurring_service.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrInvalidRecurringTask - takrorlanuvchi task ta'rifi noto'g'ri
+var ErrInvalidRecurringTask = errors.New("takrorlanuvchi task ma'lumotlari noto'g'ri")
+
+// cronParser - standart 5 maydonli cron ifodalari va @hourly, @daily kabi deskriptorlar
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// RecurringService - cron bo'yicha takrorlanuvchi tasklarni boshqaradi va
+// har bir ishga tushish vaqtida ulardan oddiy task yaratadi
+type RecurringService struct {
+	storage  storage.IStorage
+	logger   *slog.Logger
+	tasks    *TaskService
+	interval time.Duration // Vaqti kelgan ta'riflarni tekshirish oralig'i
+	stop     chan struct{}
+}
+
+// NewRecurringService - yangi RecurringService yaratish
+func NewRecurringService(
+	pdb storage.IStorage,
+	logger *slog.Logger,
+	tasks *TaskService,
+	interval time.Duration,
+) *RecurringService {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+
+	return &RecurringService{
+		storage:  pdb,
+		logger:   logger,
+		tasks:    tasks,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start - vaqti kelgan ta'riflarni davriy tekshirishni boshlash
+func (s *RecurringService) Start() {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			s.fire()
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop - yangi ishga tushishlarni yaratishni to'xtatish
+func (s *RecurringService) Stop() {
+	close(s.stop)
+}
+
+// fire - vaqti kelgan barcha ta'riflar uchun task yaratish
+func (s *RecurringService) fire() {
+	created, err := s.storage.RecurringTask().FireDue(context.Background(), time.Now(), func(rt db.RecurringTask) (time.Time, error) {
+		next, err := nextRun(rt.CronExpr, rt.Timezone, time.Now())
+		if err != nil {
+			// FireDue bu ta'rifni to'xtatadi, qolganlari ishga tushadi
+			s.logger.Error("Takrorlanuvchi taskning keyingi vaqtini hisoblab bo'lmadi, u to'xtatildi",
+				"recurring_task_id", rt.ID, "cron_expr", rt.CronExpr, "timezone", rt.Timezone, "error", err)
+		}
+		return next, err
+	})
+	if err != nil {
+		s.logger.Error("Takrorlanuvchi tasklarni ishga tushirishda xato", "error", err)
+		return
+	}
+
+	for i := range created {
+		s.logger.Info("Takrorlanuvchi taskdan task yaratildi",
+			"task_id", created[i].ID,
+			"recurring_task_id", *created[i].RecurringTaskID,
+		)
+		s.tasks.workerPool.Enqueue(&created[i])
+	}
+}
+
+// nextRun - cron ifodasi bo'yicha after dan keyingi ishga tushish vaqti
+func nextRun(expr, timezone string, after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: noto'g'ri timezone: %s", ErrInvalidRecurringTask, timezone)
+	}
+
+	schedule, err := cronParser.Parse(expr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: noto'g'ri cron ifodasi: %v", ErrInvalidRecurringTask, err)
+	}
+
+	return schedule.Next(after.In(loc)), nil
+}
+
+// CreateRecurringTask - yangi takrorlanuvchi task yaratish
+func (s *RecurringService) CreateRecurringTask(ctx context.Context, req db.RecurringTask) (*db.RecurringTask, error) {
+	s.logger.Info("Takrorlanuvchi task yaratish", "cron", req.CronExpr, "timezone", req.Timezone)
+
+	// Task maydonlari oddiy task bilan bir xil qoidalar bo'yicha tekshiriladi
+	template := db.Task{
+		Title:          req.Title,
+		Type:           req.Type,
+		Priority:       req.Priority,
+		Queue:          req.Queue,
+		MaxRetries:     req.MaxRetries,
+		RetryPolicy:    req.RetryPolicy,
+		TimeoutSeconds: req.TimeoutSeconds,
+	}
+	if err := validateTask(&template); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecurringTask, err)
+	}
+	req.Priority = template.Priority
+	req.Queue = template.Queue
+	req.MaxRetries = template.MaxRetries
+
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+
+	next, err := nextRun(req.CronExpr, req.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	req.NextRunAt = next
+
+	id, err := s.storage.RecurringTask().CreateRecurringTask(ctx, req)
+	if err != nil {
+		s.logger.Error("Takrorlanuvchi taskni saqlashda xato", "error", err)
+		return nil, fmt.Errorf("takrorlanuvchi taskni saqlashda xato: %w", err)
+	}
+
+	return s.GetRecurringTask(ctx, id)
+}
+
+// GetRecurringTask - takrorlanuvchi taskni ID bo'yicha olish
+func (s *RecurringService) GetRecurringTask(ctx context.Context, id string) (*db.RecurringTask, error) {
+	rt, err := s.storage.RecurringTask().GetRecurringTask(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrRecurringTaskNotFound) {
+			s.logger.Error("Takrorlanuvchi taskni olishda xato", "id", id, "error", err)
+		}
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// ListRecurringTasks - takrorlanuvchi tasklar ro'yxati
+func (s *RecurringService) ListRecurringTasks(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]db.RecurringTask, error) {
+	list, err := s.storage.RecurringTask().ListRecurringTasks(ctx, filters, limit, offset)
+	if err != nil {
+		s.logger.Error("Takrorlanuvchi tasklar ro'yxatini olishda xato", "error", err)
+		return nil, fmt.Errorf("ro'yxatni olishda xato: %w", err)
+	}
+	return list, nil
+}
+
+// PauseRecurringTask - takrorlanuvchi taskni to'xtatib turish
+func (s *RecurringService) PauseRecurringTask(ctx context.Context, id string) (*db.RecurringTask, error) {
+	rt, err := s.GetRecurringTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.RecurringTask().SetPaused(ctx, id, true, rt.NextRunAt); err != nil {
+		return nil, fmt.Errorf("to'xtatishda xato: %w", err)
+	}
+
+	s.logger.Info("Takrorlanuvchi task to'xtatildi", "id", id)
+	return s.GetRecurringTask(ctx, id)
+}
+
+// ResumeRecurringTask - to'xtatilgan taskni davom ettirish.
+// Pauza paytida o'tib ketgan ishga tushishlar bajarilmaydi: keyingi vaqt hozirdan hisoblanadi.
+func (s *RecurringService) ResumeRecurringTask(ctx context.Context, id string) (*db.RecurringTask, error) {
+	rt, err := s.GetRecurringTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := nextRun(rt.CronExpr, rt.Timezone, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.RecurringTask().SetPaused(ctx, id, false, next); err != nil {
+		return nil, fmt.Errorf("davom ettirishda xato: %w", err)
+	}
+
+	s.logger.Info("Takrorlanuvchi task davom ettirildi", "id", id, "next_run_at", next)
+	return s.GetRecurringTask(ctx, id)
+}
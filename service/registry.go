@@ -0,0 +1,51 @@
+// service/registry.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrUnknownTaskType - task turi uchun handler ro'yxatdan o'tkazilmagan
+var ErrUnknownTaskType = errors.New("noma'lum task turi")
+
+// TaskHandler - ma'lum turdagi tasklarni bajaruvchi.
+// payload - taskning JSON payloadi map ko'rinishida.
+type TaskHandler interface {
+	Handle(ctx context.Context, task *db.Task, payload map[string]interface{}) error
+}
+
+// TaskHandlerFunc - oddiy funksiyani TaskHandler sifatida ishlatish uchun adapter
+type TaskHandlerFunc func(ctx context.Context, task *db.Task, payload map[string]interface{}) error
+
+// Handle - TaskHandler interfeysini qanoatlantiradi
+func (f TaskHandlerFunc) Handle(ctx context.Context, task *db.Task, payload map[string]interface{}) error {
+	return f(ctx, task, payload)
+}
+
+// handlerRegistry - task turi bo'yicha handlerlar ro'yxati
+type handlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]TaskHandler
+}
+
+func newHandlerRegistry() *handlerRegistry {
+	return &handlerRegistry{
+		handlers: make(map[string]TaskHandler),
+	}
+}
+
+func (r *handlerRegistry) register(taskType string, handler TaskHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[taskType] = handler
+}
+
+func (r *handlerRegistry) get(taskType string) (TaskHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[taskType]
+	return handler, ok
+}
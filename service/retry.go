@@ -0,0 +1,132 @@
+// service/retry_policy.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrNonRetryable - handler shu xato bilan o'ralgan xato qaytarsa task qayta urinilmaydi
+var ErrNonRetryable = errors.New("qayta urinib bo'lmaydigan xato")
+
+// nonRetryableError - asl xato matnini saqlagan holda uni ErrNonRetryable deb belgilaydi
+type nonRetryableError struct {
+	err error
+}
+
+func (e *nonRetryableError) Error() string        { return e.err.Error() }
+func (e *nonRetryableError) Unwrap() error        { return e.err }
+func (e *nonRetryableError) Is(target error) bool { return target == ErrNonRetryable }
+
+// NonRetryable - handler xatosini qayta urinib bo'lmaydigan deb belgilash.
+// Masalan, noto'g'ri payload: qayta urinish natijani o'zgartirmaydi.
+//
+//	return service.NonRetryable(fmt.Errorf("email manzili yo'q"))
+func NonRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &nonRetryableError{err: err}
+}
+
+// Chegaralar: kechikish time.Duration ga sig'ishi va task cheksiz qayta urinilmasligi uchun
+const (
+	maxRetryDelay  = 7 * 24 * time.Hour // Bitta kechikishning yuqori chegarasi
+	maxTaskRetries = 100                // max_retries ning yuqori chegarasi
+)
+
+// defaultRetryPolicy - task siyosatsiz yaratilganda: 2, 4, 8... soniya, ko'pi bilan 1 soat
+var defaultRetryPolicy = db.RetryPolicy{
+	Strategy: db.RetryExponential,
+	Delay:    1,
+	MaxDelay: 3600,
+}
+
+// validateRetryPolicy - siyosat va max_retries ni tekshirish, standart qiymatlarni qo'yish.
+// Oxirgi urinishdan oldingi kechikish maxRetryDelay dan oshadigan siyosat rad etiladi:
+// masalan max_delay siz exponential ko'p urinish bilan.
+func validateRetryPolicy(p *db.RetryPolicy, maxRetries int) error {
+	if maxRetries > maxTaskRetries {
+		return fmt.Errorf("%w: max_retries %d dan oshmasligi kerak", ErrInvalidTask, maxTaskRetries)
+	}
+	if p == nil {
+		return nil
+	}
+
+	switch p.Strategy {
+	case db.RetryNone, db.RetryFixed, db.RetryLinear, db.RetryExponential:
+	case "":
+		p.Strategy = db.RetryExponential
+	default:
+		return fmt.Errorf("%w: noma'lum retry strategiyasi: %s", ErrInvalidTask, p.Strategy)
+	}
+
+	if p.Delay < 0 || p.MaxDelay < 0 {
+		return fmt.Errorf("%w: retry kechikishi manfiy bo'lishi mumkin emas", ErrInvalidTask)
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("%w: jitter 0 dan 1 gacha bo'lishi kerak", ErrInvalidTask)
+	}
+	if p.Delay == 0 && p.Strategy != db.RetryNone {
+		p.Delay = 1
+	}
+
+	limit := maxRetryDelay.Seconds()
+	if float64(p.Delay) > limit || float64(p.MaxDelay) > limit {
+		return fmt.Errorf("%w: retry kechikishi %d soniyadan oshmasligi kerak", ErrInvalidTask, int(limit))
+	}
+	if p.Strategy != db.RetryNone && p.MaxDelay == 0 && retrySeconds(p, maxRetries) > limit {
+		return fmt.Errorf("%w: %d ta urinishda kechikish %d soniyadan oshadi, max_delay ko'rsating yoki max_retries ni kamaytiring",
+			ErrInvalidTask, maxRetries, int(limit))
+	}
+	return nil
+}
+
+// shouldRetry - xato va siyosat bo'yicha task yana urinilishi mumkinmi
+// (urinishlar soni chegarasi alohida tekshiriladi)
+func shouldRetry(p *db.RetryPolicy, err error) bool {
+	if errors.Is(err, ErrNonRetryable) || errors.Is(err, ErrUnknownTaskType) {
+		return false
+	}
+	return p == nil || p.Strategy != db.RetryNone
+}
+
+// retrySeconds - siyosat bo'yicha retries marta xato bo'lgandan keyingi kechikish
+// (max_delay, jitter va maxRetryDelay hisobga olinmagan)
+func retrySeconds(p *db.RetryPolicy, retries int) float64 {
+	base := float64(p.Delay)
+	switch p.Strategy {
+	case db.RetryFixed:
+		return base
+	case db.RetryLinear:
+		return base * float64(retries)
+	default:
+		// 2^64 dan katta daraja baribir maxRetryDelay ga kesiladi
+		return base * math.Pow(2, float64(min(retries, 64)))
+	}
+}
+
+// retryDelay - retries marta xato bo'lgandan keyingi kutish vaqti. Natija
+// maxRetryDelay dan oshmaydi: katta daraja time.Duration ni to'ldirib manfiy
+// (ya'ni darhol qayta urinish) bo'lib qolmasin.
+func retryDelay(p *db.RetryPolicy, retries int) time.Duration {
+	if p == nil {
+		p = &defaultRetryPolicy
+	}
+
+	seconds := retrySeconds(p, retries)
+	if p.MaxDelay > 0 && seconds > float64(p.MaxDelay) {
+		seconds = float64(p.MaxDelay)
+	}
+	seconds = min(seconds, maxRetryDelay.Seconds())
+	if p.Jitter > 0 {
+		// Bir vaqtda yiqilgan tasklar bir vaqtda qaytib kelmasligi uchun
+		seconds -= seconds * p.Jitter * rand.Float64()
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryDelaySaturates(t *testing.T) {
+	// max_delay siz exponential: 2^n soniya time.Duration ni to'ldirmasligi kerak
+	p := &db.RetryPolicy{Strategy: db.RetryExponential, Delay: 1}
+	for _, retries := range []int{33, 40, 63, 64, 100, 1000} {
+		if d := retryDelay(p, retries); d != maxRetryDelay {
+			t.Errorf("retryDelay(exponential, %d) = %v, want %v", retries, d, maxRetryDelay)
+		}
+	}
+
+	if d := retryDelay(p, 3); d != 8*time.Second {
+		t.Errorf("retryDelay(exponential, 3) = %v, want 8s", d)
+	}
+
+	linear := &db.RetryPolicy{Strategy: db.RetryLinear, Delay: 86400}
+	if d := retryDelay(linear, 50); d != maxRetryDelay {
+		t.Errorf("retryDelay(linear, 50) = %v, want %v", d, maxRetryDelay)
+	}
+
+	jittered := &db.RetryPolicy{Strategy: db.RetryExponential, Delay: 1, Jitter: 0.5}
+	if d := retryDelay(jittered, 200); d <= 0 || d > maxRetryDelay {
+		t.Errorf("retryDelay(jittered, 200) = %v, want within (0, %v]", d, maxRetryDelay)
+	}
+}
+
+func TestValidateRetryPolicyLimits(t *testing.T) {
+	for name, tc := range map[string]struct {
+		policy     *db.RetryPolicy
+		maxRetries int
+		ok         bool
+	}{
+		"default policy":                {nil, 3, true},
+		"too many retries":              {nil, maxTaskRetries + 1, false},
+		"exponential small":             {&db.RetryPolicy{Strategy: db.RetryExponential}, 10, true},
+		"exponential unbounded":         {&db.RetryPolicy{Strategy: db.RetryExponential}, 40, false},
+		"exponential capped":            {&db.RetryPolicy{Strategy: db.RetryExponential, MaxDelay: 3600}, 100, true},
+		"max_delay too large":           {&db.RetryPolicy{Strategy: db.RetryExponential, MaxDelay: 30 * 86400}, 3, false},
+		"fixed delay too large":         {&db.RetryPolicy{Strategy: db.RetryFixed, Delay: 30 * 86400}, 3, false},
+		"linear within limit":           {&db.RetryPolicy{Strategy: db.RetryLinear, Delay: 60}, 100, true},
+		"none ignores delay arithmetic": {&db.RetryPolicy{Strategy: db.RetryNone}, 100, true},
+	} {
+		err := validateRetryPolicy(tc.policy, tc.maxRetries)
+		if tc.ok && err != nil {
+			t.Errorf("%s: validateRetryPolicy = %v, want nil", name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrInvalidTask) {
+			t.Errorf("%s: validateRetryPolicy = %v, want ErrInvalidTask", name, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// service/scheduler.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"container/heap"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Scheduler - kelajakda bajarilishi kerak bo'lgan tasklarni (scheduled_at, next_retry_at)
+// vaqti kelguncha ushlab turadi va vaqti kelganda uni WorkerPool ga uzatadi.
+// Holat faqat xotirada saqlanadi: restartdan keyin kutilayotgan tasklar bazadan qayta yuklanadi.
+type Scheduler struct {
+	db             storage.IStorage
+	logger         *slog.Logger
+	dispatch       func(task *db.Task) // Vaqti kelgan taskni navbatga yuborish va workerni uyg'otish
+	reloadInterval time.Duration
+
+	mu      sync.Mutex
+	running bool
+	items   delayHeap
+	planned map[string]time.Time // task ID -> rejalashtirilgan vaqt (dublikatlarning oldini olish)
+	changed chan struct{}        // Eng yaqin vaqt o'zgarganini bildiradi
+}
+
+// NewScheduler - yangi Scheduler yaratish
+func NewScheduler(
+	db storage.IStorage,
+	logger *slog.Logger,
+	dispatch func(task *db.Task),
+	reloadInterval time.Duration,
+) *Scheduler {
+	if reloadInterval <= 0 {
+		reloadInterval = 30 * time.Second
+	}
+
+	return &Scheduler{
+		db:             db,
+		logger:         logger,
+		dispatch:       dispatch,
+		reloadInterval: reloadInterval,
+		planned:        make(map[string]time.Time),
+		changed:        make(chan struct{}, 1),
+	}
+}
+
+// Start - schedulerni ishga tushirish
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.running = true
+	s.mu.Unlock()
+
+	go s.run()
+}
+
+// Schedule - taskni berilgan vaqtda dispatch qilish uchun rejalashtirish
+func (s *Scheduler) Schedule(task *db.Task, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Ishlamayotgan scheduler (masalan faqat API instance) xotirada hech narsa yig'maydi:
+	// task baribir bazada, uni workerlar ishlayotgan instance yuklab oladi
+	if !s.running {
+		return
+	}
+	if planned, ok := s.planned[task.ID]; ok && planned.Equal(at) {
+		return
+	}
+
+	s.planned[task.ID] = at
+	heap.Push(&s.items, delayItem{task: *task, at: at})
+
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// run - asosiy sikl: eng yaqin vaqtni kutish va vaqti kelganlarni dispatch qilish
+func (s *Scheduler) run() {
+	s.reload()
+
+	reload := time.NewTicker(s.reloadInterval)
+	defer reload.Stop()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(s.fireDue())
+
+		select {
+		case <-timer.C:
+		case <-s.changed:
+		case <-reload.C:
+			s.reload()
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// fireDue - vaqti kelgan tasklarni dispatch qilish; keyingi task gacha qolgan vaqtni qaytaradi
+func (s *Scheduler) fireDue() time.Duration {
+	now := time.Now()
+	var due []db.Task
+
+	s.mu.Lock()
+	for s.items.Len() > 0 {
+		next := s.items[0]
+		if next.at.After(now) {
+			break
+		}
+		heap.Pop(&s.items)
+
+		// Eskirgan yozuv (task boshqa vaqtga qayta rejalashtirilgan)
+		if planned, ok := s.planned[next.task.ID]; !ok || !planned.Equal(next.at) {
+			continue
+		}
+		delete(s.planned, next.task.ID)
+		due = append(due, next.task)
+	}
+
+	wait := s.reloadInterval
+	if s.items.Len() > 0 {
+		wait = time.Until(s.items[0].at)
+	}
+	s.mu.Unlock()
+
+	for i := range due {
+		s.dispatch(&due[i])
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// reload - bazadan yaqin orada bajarilishi kerak bo'lgan tasklarni yuklash
+func (s *Scheduler) reload() {
+	until := time.Now().Add(2 * s.reloadInterval)
+
+	tasks, err := s.db.Task().ListDelayedTasks(context.Background(), until)
+	if err != nil {
+		s.logger.Error("Rejalashtirilgan tasklarni yuklashda xato", "error", err)
+		return
+	}
+
+	for i := range tasks {
+		s.Schedule(&tasks[i], readyAt(&tasks[i]))
+	}
+}
+
+// readyAt - task navbatdan olinishi mumkin bo'lgan vaqt (scheduled_at va next_retry_at dan kechrog'i)
+func readyAt(task *db.Task) time.Time {
+	at := task.CreatedAt
+	if task.ScheduledAt.Valid && task.ScheduledAt.Time.After(at) {
+		at = task.ScheduledAt.Time
+	}
+	if task.NextRetryAt != nil && task.NextRetryAt.After(at) {
+		at = *task.NextRetryAt
+	}
+	return at
+}
+
+// delayItem - schedulerdagi bitta yozuv
+type delayItem struct {
+	task db.Task // Navbatga yuborish uchun (ID, queue, type)
+	at   time.Time
+}
+
+// delayHeap - vaqt bo'yicha min-heap
+type delayHeap []delayItem
+
+func (h delayHeap) Len() int           { return len(h) }
+func (h delayHeap) Less(i, j int) bool { return h[i].at.Before(h[j].at) }
+func (h delayHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *delayHeap) Push(x interface{}) {
+	*h = append(*h, x.(delayItem))
+}
+
+func (h *delayHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
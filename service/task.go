@@ -2,42 +2,70 @@
 package service
 
 import (
+	"asynchronous/config"
 	"asynchronous/model/db"
 	"asynchronous/storage"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
-	"math"
 	"time"
-
-	"github.com/google/uuid"
 )
 
+// ErrInvalidTask - task maydonlari noto'g'ri to'ldirilgan
+var ErrInvalidTask = errors.New("task ma'lumotlari noto'g'ri")
+
+// ErrInvalidTaskState - taskning joriy holati so'ralgan amalga ruxsat bermaydi
+var ErrInvalidTaskState = errors.New("task holati bu amalga ruxsat bermaydi")
+
 // TaskService - tasklarni boshqarish uchun asosiy service
 type TaskService struct {
 	storage    storage.IStorage
 	logger     *slog.Logger
-	taskQueue  chan *db.Task // Tasklar uchun kanal
-	workerPool *WorkerPool   // Workerlar pooli
+	workerPool *WorkerPool      // Workerlar pooli
+	handlers   *handlerRegistry // Task turi bo'yicha handlerlar
+
+	idempotencyRetention time.Duration // Idempotency kaliti amal qiladigan muddat
 }
 
 // NewTaskService - yangi TaskService yaratish
 func NewTaskService(
 	pdb storage.IStorage,
 	logger *slog.Logger,
-	workerCount int,
+	cfg config.WorkerConfig,
+	queue TaskQueue,
 ) *TaskService {
-	// Kanalni initsializatsiya qilish (buffer size 1000)
-	taskQueue := make(chan *db.Task, 1000)
+	handlers := newHandlerRegistry()
 
-	return &TaskService{
+	s := &TaskService{
 		storage:    pdb,
 		logger:     logger,
-		taskQueue:  taskQueue,
-		workerPool: NewWorkerPool(pdb, logger, workerCount, taskQueue),
+		workerPool: NewWorkerPool(pdb, logger, cfg, queue, handlers),
+		handlers:   handlers,
+
+		idempotencyRetention: cfg.IdempotencyRetention,
+	}
+	if s.idempotencyRetention <= 0 {
+		s.idempotencyRetention = 24 * time.Hour
+	}
+	s.workerPool.OnFinished(s.batchTaskFinished)
+
+	return s
+}
+
+// RegisterHandler - task turi uchun handlerni ro'yxatdan o'tkazish.
+// Bir xil tur qayta ro'yxatdan o'tkazilsa, eski handler almashtiriladi.
+func (s *TaskService) RegisterHandler(taskType string, handler TaskHandler) error {
+	if taskType == "" {
+		return errors.New("task turi bo'sh bo'lishi mumkin emas")
 	}
+	if handler == nil {
+		return errors.New("handler nil bo'lishi mumkin emas")
+	}
+
+	s.handlers.register(taskType, handler)
+	s.logger.Info("Task handler ro'yxatdan o'tdi", "type", taskType)
+	return nil
 }
 
 // StartWorkers - workerlarni ishga tushirish
@@ -45,184 +73,234 @@ func (s *TaskService) StartWorkers() {
 	s.workerPool.Start()
 }
 
-// CreateTask - yangi task yaratish va navbatga qo'shish
+// StopWorkers - workerlarni to'xtatish; ctx tugaguncha bajarilayotgan tasklar kutiladi
+func (s *TaskService) StopWorkers(ctx context.Context) error {
+	return s.workerPool.Stop(ctx)
+}
+
+// CreateTask - yangi task yaratish va navbatga qo'shish.
+// Navbat - bu tasks jadvalining o'zi: task bazaga yozilgan zahoti u yo'qolmaydi.
 func (s *TaskService) CreateTask(ctx context.Context, req db.Task) (*db.Task, error) {
+	task, _, err := s.CreateTaskOnce(ctx, req, nil)
+	return task, err
+}
+
+// CreateTaskOnce - CreateTask bilan bir xil, lekin takroriy task yaratilmaydi:
+//   - task idempotency kalitiga ega va shu kalit bilan task avval yaratilgan bo'lsa;
+//   - unique berilgan va xuddi shunday task pending/processing bo'lsa
+//     (reject da ErrDuplicateTask, merge da mavjud task qaytadi).
+//
+// Ikkala holatda ham mavjud task created=false bilan qaytadi.
+func (s *TaskService) CreateTaskOnce(ctx context.Context, req db.Task, unique *UniqueOptions) (*db.Task, bool, error) {
 	// Validatsiyalar
-	if req.Title == "" {
-		return nil, errors.New("title bo'sh bo'lishi mumkin emas")
+	if err := validateTask(&req); err != nil {
+		return nil, false, err
+	}
+	if unique != nil {
+		if err := validateUniqueOptions(unique); err != nil {
+			return nil, false, err
+		}
+		key, err := uniqueKey(req.Type, req.Payload, unique.Key)
+		if err != nil {
+			return nil, false, err
+		}
+		req.UniqueKey = &key
 	}
 
 	// Avtomatik to'ldirish
-	req.ID = uuid.NewString()
 	req.Status = "pending"
 	req.CreatedAt = time.Now()
 	req.UpdatedAt = time.Now()
 
 	// Bazaga saqlash
-	if _, err := s.storage.Task().CreateTask(ctx, req); err != nil {
-		return nil, fmt.Errorf("taskni saqlashda xato: %w", err)
-	}
-
-	// Navbatga pointer orqali qo'shish
-	go func(task db.Task) {
-		select {
-		case s.taskQueue <- &task:
-			s.logger.Info("Task navbatga qo'shildi", "task_id", task.ID)
-		default:
-			s.logger.Error("Navbat to'ldi, task qabul qilinmadi")
+	if req.IdempotencyKey != nil || req.UniqueKey != nil {
+		task, created, err := s.createTaskOnce(ctx, req)
+		if err != nil {
+			return nil, false, fmt.Errorf("taskni saqlashda xato: %w", err)
+		}
+		if !created {
+			if req.IdempotencyKey != nil && task.IdempotencyKey != nil && *task.IdempotencyKey == *req.IdempotencyKey {
+				s.logger.Info("Takroriy so'rov: mavjud task qaytarildi", "task_id", task.ID, "idempotency_key", *req.IdempotencyKey)
+				return &task, false, nil
+			}
+			if unique == nil || unique.OnConflict == UniqueReject {
+				return nil, false, fmt.Errorf("%w: %s", ErrDuplicateTask, task.ID)
+			}
+			s.logger.Info("Unikal task: mavjud task bilan birlashtirildi", "task_id", task.ID, "type", task.Type)
+			return &task, false, nil
+		}
+		req = task
+	} else {
+		id, err := s.storage.Task().CreateTask(ctx, req)
+		if err != nil {
+			return nil, false, fmt.Errorf("taskni saqlashda xato: %w", err)
 		}
-	}(req)
+		req.ID = id
+	}
 
-	return &req, nil
-}
+	// Vaqti kelgan bo'lsa workerni uyg'otish, aks holda schedulerga topshirish
+	s.workerPool.Enqueue(&req)
+	s.logger.Info("Task navbatga qo'shildi", "task_id", req.ID)
 
-// WorkerPool - tasklarni bajaruvchi ishchilar pooli
-type WorkerPool struct {
-	db          storage.IStorage
-	logger      *slog.Logger
-	workerCount int
-	taskQueue   chan *db.Task
+	return &req, true, nil
 }
 
-// NewWorkerPool - yangi WorkerPool yaratish
-func NewWorkerPool(
-	db storage.IStorage,
-	logger *slog.Logger,
-	workerCount int,
-	taskQueue chan *db.Task, // Chanelni parameter sifatida qabul qilish
-) *WorkerPool {
-	return &WorkerPool{
-		db:          db,
-		logger:      logger,
-		workerCount: workerCount,
-		taskQueue:   taskQueue, // Kanalni saqlash
+// createTaskOnce - mos task saqlash va qidirish oralig'ida yakunlanib qolsa
+// (storage ErrTaskNotFound qaytaradi) yozish qayta uriniladi
+func (s *TaskService) createTaskOnce(ctx context.Context, req db.Task) (db.Task, bool, error) {
+	for attempt := 1; ; attempt++ {
+		task, created, err := s.storage.Task().CreateTaskOnce(ctx, req, s.idempotencyRetention)
+		if errors.Is(err, storage.ErrTaskNotFound) && attempt < 3 {
+			continue
+		}
+		return task, created, err
 	}
 }
 
-// Start - workerlarni ishga tushirish
-func (wp *WorkerPool) Start() {
-	for i := 0; i < wp.workerCount; i++ {
-		workerID := i + 1
-		go wp.worker(workerID)
+// validateTask - yangi task maydonlarini tekshirish va standart qiymatlarni qo'yish
+func validateTask(task *db.Task) error {
+	if task.Title == "" {
+		return fmt.Errorf("%w: title bo'sh bo'lishi mumkin emas", ErrInvalidTask)
 	}
+	if task.Type == "" {
+		return fmt.Errorf("%w: type bo'sh bo'lishi mumkin emas", ErrInvalidTask)
+	}
+	if task.Priority == 0 {
+		task.Priority = 3
+	}
+	if task.Priority < 1 || task.Priority > 5 {
+		return fmt.Errorf("%w: priority 1 dan 5 gacha bo'lishi kerak", ErrInvalidTask)
+	}
+	if err := validateQueue(&task.Queue); err != nil {
+		return err
+	}
+	if task.MaxRetries <= 0 {
+		task.MaxRetries = 3
+	}
+	if task.IdempotencyKey != nil && (*task.IdempotencyKey == "" || len(*task.IdempotencyKey) > 255) {
+		return fmt.Errorf("%w: idempotency_key 1 dan 255 belgigacha bo'lishi kerak", ErrInvalidTask)
+	}
+	if task.TimeoutSeconds < 0 {
+		return fmt.Errorf("%w: timeout_seconds manfiy bo'lishi mumkin emas", ErrInvalidTask)
+	}
+	return validateRetryPolicy(task.RetryPolicy, task.MaxRetries)
 }
 
-// worker - har bir individual ishchi
-func (wp *WorkerPool) worker(workerID int) {
-	wp.logger.Info("Worker ishga tushdi", "worker_id", workerID)
-
-	for task := range wp.taskQueue {
-		wp.processTask(workerID, task)
+// GetTask - taskni ID bo'yicha olish
+func (s *TaskService) GetTask(ctx context.Context, taskID string) (*db.Task, error) {
+	task, err := s.storage.Task().GetTask(ctx, taskID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTaskNotFound) {
+			s.logger.Error("Taskni olishda xato", "task_id", taskID, "error", err)
+		}
+		return nil, err
 	}
+	return &task, nil
 }
 
-// processTask - taskni bajarish logikasi
-func (wp *WorkerPool) processTask(workerID int, task *db.Task) {
-	// ctx := context.Background()
-
-	// 1. Statusni "processing" ga o'zgartirish
-	if err := wp.updateTaskStatus(task, "processing"); err != nil {
-		return
+// ListTasks - filtrlar bo'yicha tasklar ro'yxati (status, creator_id, user_id)
+func (s *TaskService) ListTasks(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]db.Task, error) {
+	tasks, err := s.storage.Task().ListTasks(ctx, filters, limit, offset)
+	if err != nil {
+		s.logger.Error("Tasklar ro'yxatini olishda xato", "error", err)
+		return nil, fmt.Errorf("tasklar ro'yxatini olishda xato: %w", err)
 	}
+	return tasks, nil
+}
 
-	// 2. Taskni bajarish
-	err := wp.executeTaskLogic(task)
+// DeleteTask - taskni o'chirish (soft delete). Pending yoki bajarilayotgan task avval
+// bekor qilinadi (CancelTask kabi): handleri to'xtash signalini oladi, unga bog'liq
+// tasklar skipped bo'ladi va o'chirilgan task natijasi yozilmaydi. Batchning oxirgi
+// kutayotgan taski o'chirilsa batch yakunlanadi va uning on_complete hooki ishga tushadi.
+func (s *TaskService) DeleteTask(ctx context.Context, taskID, deletedBy string) error {
+	task, err := s.storage.Task().GetTask(ctx, taskID)
 	if err != nil {
-		wp.handleTaskError(task, err)
-		return
+		return err
 	}
 
-	// 3. Muvaffaqiyatli yakunlash
-	if err := wp.updateTaskStatus(task, "completed"); err != nil {
-		return
+	if task.Status == "pending" || task.Status == "processing" {
+		_, err := s.CancelTask(ctx, taskID, deletedBy, "task o'chirildi")
+		// Shu orada yakunlangan task shunchaki o'chiriladi
+		if err != nil && !errors.Is(err, ErrInvalidTaskState) {
+			return err
+		}
 	}
 
-	// 4. Natijani saqlash
-	if err := wp.saveTaskResult(task); err != nil {
-		wp.logger.Error("Natijani saqlashda xato", "error", err)
+	if err := s.storage.Task().DeleteTask(ctx, taskID); err != nil {
+		s.logger.Error("Taskni o'chirishda xato", "task_id", taskID, "error", err)
+		return fmt.Errorf("taskni o'chirishda xato: %w", err)
 	}
+
+	s.batchTaskFinished(&task)
+	s.logger.Info("Task o'chirildi", "task_id", taskID)
+	return nil
 }
 
-// executeTaskLogic - taskning asosiy logikasi
-func (wp *WorkerPool) executeTaskLogic(task *db.Task) error {
-	// Simulyatsiya: Taskni bajarish uchun vaqt
-	if task.ScheduledAt.Valid && time.Now().Before(task.ScheduledAt.Time) {
-		return fmt.Errorf("task hali bajarilish vaqti kelmagan")
+// ListAttempts - taskning barcha bajarilishlari (urinishlari) tarixi
+func (s *TaskService) ListAttempts(ctx context.Context, taskID string) ([]db.TaskAttempt, error) {
+	attempts, err := s.storage.TaskAttempt().ListAttempts(ctx, taskID)
+	if err != nil {
+		s.logger.Error("Urinishlar tarixini olishda xato", "task_id", taskID, "error", err)
+		return nil, fmt.Errorf("urinishlar tarixini olishda xato: %w", err)
 	}
+	return attempts, nil
+}
 
-	// Payloadni parse qilish
-	var payload map[string]interface{}
-	if err := json.Unmarshal(task.Payload, &payload); err != nil {
-		return fmt.Errorf("payloadni parse qilishda xato: %w", err)
+// CancelTask - pending yoki bajarilayotgan taskni bekor qilish. Bajarilayotgan
+// taskning handleri kontekst orqali to'xtash signalini oladi.
+func (s *TaskService) CancelTask(ctx context.Context, taskID, cancelledBy, reason string) (*db.Task, error) {
+	task, err := s.storage.Task().GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != "pending" && task.Status != "processing" {
+		return nil, fmt.Errorf("%w: %s taskni bekor qilib bo'lmaydi", ErrInvalidTaskState, task.Status)
 	}
 
-	// Asosiy logika (sizning biznes mantiqingiz)
-	wp.logger.Info("Task bajarilmoqda...", "task_id", task.ID)
-	time.Sleep(1 * time.Second) // Simulyatsiya
-
-	return nil
-}
+	task, err = s.storage.Task().CancelTask(ctx, taskID, cancelledBy, reason)
+	if errors.Is(err, storage.ErrTaskNotFound) {
+		// Shu orada task yakunlandi
+		return nil, fmt.Errorf("%w: task allaqachon yakunlangan", ErrInvalidTaskState)
+	}
+	if err != nil {
+		s.logger.Error("Taskni bekor qilishda xato", "task_id", taskID, "error", err)
+		return nil, err
+	}
 
-// handleTaskError - xatolikni boshqarish
-func (wp *WorkerPool) handleTaskError(task *db.Task, err error) {
-	wp.logger.Error("Taskda xato yuz berdi",
-		"task_id", task.ID,
-		"error", err.Error(),
-	)
-
-	// Qayta urinishlar sonini yangilash
-	task.Retries++
-
-	// Qayta urinishlar chegarasini tekshirish
-	if task.Retries >= task.MaxRetries {
-		wp.logger.Error("Maksimal qayta urinishlar soniga yetildi",
-			"task_id", task.ID,
-			"max_retries", task.MaxRetries,
-		)
-		_ = wp.updateTaskStatus(task, "failed")
-		return
-	}
-
-	// Eksponensial kechikni hisoblash
-	delay := time.Duration(math.Pow(2, float64(task.Retries))) * time.Second
-	nextRetry := time.Now().Add(delay)
-	task.NextRetryAt = &nextRetry
-
-	// Taskni yangilash
-	if err := wp.db.Task().UpdateTask(context.Background(), *task); err != nil {
-		wp.logger.Error("Taskni yangilashda xato", "error", err)
-		return
-	}
-
-	// Navbatga qayta qo'shish
-	go func(t *db.Task) {
-		time.Sleep(delay)
-		wp.taskQueue <- t
-	}(task)
+	s.workerPool.publishEvent(&task)
+	s.workerPool.finished(&task)
+	if s.workerPool.Cancel(taskID) {
+		s.logger.Info("Bajarilayotgan taskka to'xtash signali berildi", "task_id", taskID)
+	}
+	s.logger.Info("Task bekor qilindi", "task_id", taskID, "reason", reason)
+	return &task, nil
 }
 
-// updateTaskStatus - task statusini yangilash
-func (wp *WorkerPool) updateTaskStatus(task *db.Task, status string) error {
-	task.Status = status
-	task.UpdatedAt = time.Now()
+// RetryTask - muvaffaqiyatsiz tugagan taskni qaytadan navbatga qo'yish
+func (s *TaskService) RetryTask(ctx context.Context, taskID string) (*db.Task, error) {
+	task, err := s.storage.Task().GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := wp.db.Task().UpdateTask(context.Background(), *task); err != nil {
-		wp.logger.Error("Statusni yangilashda xato",
-			"task_id", task.ID,
-			"error", err.Error(),
-		)
-		return err
+	if task.Status != "failed" {
+		return nil, fmt.Errorf("%w: faqat failed taskni qayta ishga tushirish mumkin", ErrInvalidTaskState)
 	}
-	return nil
-}
 
-// saveTaskResult - task natijasini saqlash
-func (wp *WorkerPool) saveTaskResult(task *db.Task) error {
-	result := db.TaskResult{
-		ID:          uuid.NewString(),
-		TaskID:      task.ID,
-		CompletedAt: time.Now(),
+	task.Status = "pending"
+	task.Retries = 0
+	task.NextRetryAt = nil
+	if err := s.storage.Task().UpdateTask(ctx, task); err != nil {
+		if errors.Is(err, storage.ErrUniqueConflict) {
+			return nil, fmt.Errorf("%w: unique_key %s", ErrDuplicateTask, *task.UniqueKey)
+		}
+		s.logger.Error("Taskni qayta navbatga qo'yishda xato", "task_id", taskID, "error", err)
+		return nil, fmt.Errorf("taskni yangilashda xato: %w", err)
 	}
 
-	return wp.db.TaskResult().CreateResult(context.Background(), result)
+	s.unskipDependants(ctx, taskID)
+	s.workerPool.publishEvent(&task)
+	s.workerPool.Enqueue(&task)
+	s.logger.Info("Task qayta navbatga qo'yildi", "task_id", taskID)
+	return &task, nil
 }
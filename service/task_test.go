@@ -0,0 +1,99 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeWorkflow - SkipDependants chaqirilgan ota tasklar
+type fakeWorkflow struct {
+	storage.IWorkflowStorage
+
+	mu      sync.Mutex
+	skipped []string
+}
+
+func (f *fakeWorkflow) SkipDependants(ctx context.Context, taskID string) ([]db.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.skipped = append(f.skipped, taskID)
+	return nil, nil
+}
+
+// deleteTasks - bitta task: bekor qilish va o'chirish yoziladi
+type deleteTasks struct {
+	storage.ITaskStorage
+
+	task      db.Task
+	cancelled string // CancelTask dagi cancelledBy
+	deleted   bool
+}
+
+func (f *deleteTasks) GetTask(ctx context.Context, id string) (db.Task, error) {
+	if f.deleted {
+		return db.Task{}, storage.ErrTaskNotFound
+	}
+	return f.task, nil
+}
+
+func (f *deleteTasks) CancelTask(ctx context.Context, id, cancelledBy, reason string) (db.Task, error) {
+	f.cancelled = cancelledBy
+	f.task.Status = "cancelled"
+	return f.task, nil
+}
+
+func (f *deleteTasks) DeleteTask(ctx context.Context, id string) error {
+	f.deleted = true
+	return nil
+}
+
+func newDeleteEnv(task db.Task) (*TaskService, *deleteTasks, *fakeWorkflow) {
+	tasks := &deleteTasks{task: task}
+	workflow := &fakeWorkflow{}
+	pdb := &fakeStorage{task: tasks, workflow: workflow, event: nopEvents{}, webhook: nopWebhooks{}}
+	s := &TaskService{
+		storage:    pdb,
+		logger:     discardLogger(),
+		workerPool: NewWorkerPool(pdb, discardLogger(), config.WorkerConfig{}, &pushQueue{}, newHandlerRegistry()),
+	}
+	return s, tasks, workflow
+}
+
+func TestDeleteRunningTaskCancelsHandlerAndSkipsDependants(t *testing.T) {
+	s, tasks, workflow := newDeleteEnv(db.Task{ID: "parent", Status: "processing"})
+	ctx := s.workerPool.track(&tasks.task, "worker-1")
+
+	if err := s.DeleteTask(context.Background(), "parent", "user-1"); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	if !tasks.deleted || tasks.cancelled != "user-1" {
+		t.Fatalf("task deleted = %v, cancelled by %q; want cancelled by user-1 then deleted", tasks.deleted, tasks.cancelled)
+	}
+	if !errors.Is(context.Cause(ctx), ErrTaskCancelled) {
+		t.Fatalf("handler context cause = %v, want ErrTaskCancelled", context.Cause(ctx))
+	}
+	// Handler qaytganda natijasi o'chirilgan taskka yozilmaydi
+	if released := s.workerPool.untrack("parent"); released != db.AttemptCancelled {
+		t.Fatalf("released = %q, want %q", released, db.AttemptCancelled)
+	}
+	if len(workflow.skipped) != 1 || workflow.skipped[0] != "parent" {
+		t.Fatalf("skipped dependants of %v, want [parent]", workflow.skipped)
+	}
+}
+
+func TestDeleteFinishedTaskDoesNotCancel(t *testing.T) {
+	s, tasks, workflow := newDeleteEnv(db.Task{ID: "done", Status: "completed"})
+
+	if err := s.DeleteTask(context.Background(), "done", "user-1"); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if !tasks.deleted || tasks.cancelled != "" || len(workflow.skipped) != 0 {
+		t.Fatalf("deleted = %v, cancelled by %q, skipped %v; want only deleted", tasks.deleted, tasks.cancelled, workflow.skipped)
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	redisstore "asynchronous/storage/redis"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Navbat backendlari (WORKER_QUEUE_BACKEND)
+const (
+	QueueBackendPostgres = "postgres"
+	QueueBackendRedis    = "redis"
+)
+
+// TaskQueue - tayyor tasklarni workerlarga yetkazuvchi transport. Tasklarning o'zi va
+// holati har doim Postgresda: navbat faqat "qaysi taskni olish kerak" degan xabarni tashiydi.
+type TaskQueue interface {
+	// Push - bajarishga tayyor task haqida xabar berish
+	Push(ctx context.Context, task *db.Task) error
+	// Pop - opts.Queues tartibida navbatdan taskni band qilib olish; bo'sh bo'lsa storage.ErrNoTask
+	Pop(ctx context.Context, opts storage.ClaimOptions) (*Delivery, error)
+	// Ack - task bilan ish tugadi (bajarildi yoki bazada navbatga qaytarildi)
+	Ack(ctx context.Context, d *Delivery) error
+	// Leave - worker to'xtadi: consumer sifatidagi izi (tasdiqlanmagan xabari qolmagan
+	// bo'lsa) o'chiriladi
+	Leave(ctx context.Context, consumer string) error
+}
+
+// Delivery - navbatdan olingan va "processing" holatiga o'tkazilgan task
+type Delivery struct {
+	Task db.Task
+	msg  *redisstore.StreamMessage // Redis backendida tasdiqlanadigan xabar
+}
+
+// NewTaskQueue - sozlamadagi backend bo'yicha navbat yaratish
+func NewTaskQueue(cfg config.WorkerConfig, pdb storage.IStorage, logger *slog.Logger) (TaskQueue, error) {
+	switch cfg.QueueBackend {
+	case "", QueueBackendPostgres:
+		return newPostgresQueue(pdb), nil
+	case QueueBackendRedis:
+		rdb := redisstore.ConnectDB()
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			return nil, fmt.Errorf("redisga ulanishda xato: %w", err)
+		}
+		return newRedisQueue(pdb, redisstore.NewTaskStream(rdb), logger, cfg), nil
+	default:
+		return nil, fmt.Errorf("noma'lum navbat backendi: %q (postgres yoki redis)", cfg.QueueBackend)
+	}
+}
+
+// postgresQueue - workerlar tasklarni to'g'ridan-to'g'ri tasks jadvalidan band qiladi.
+// Xabar yuborish shart emas: pool Notify orqali kutayotgan workerni uyg'otadi.
+type postgresQueue struct {
+	db storage.IStorage
+}
+
+func newPostgresQueue(pdb storage.IStorage) *postgresQueue {
+	return &postgresQueue{db: pdb}
+}
+
+func (q *postgresQueue) Push(ctx context.Context, task *db.Task) error {
+	return nil
+}
+
+func (q *postgresQueue) Pop(ctx context.Context, opts storage.ClaimOptions) (*Delivery, error) {
+	task, err := q.db.Task().ClaimTask(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Delivery{Task: task}, nil
+}
+
+func (q *postgresQueue) Ack(ctx context.Context, d *Delivery) error {
+	return nil
+}
+
+func (q *postgresQueue) Leave(ctx context.Context, consumer string) error {
+	return nil
+}
+
+// redisScanLimit - bitta Pop da ko'rib chiqiladigan xabarlarning maksimal soni
+// (cheklangan turlar va eskirgan xabarlar tufayli sikl cheksiz aylanmasligi uchun)
+const redisScanLimit = 20
+
+// redisQueue - Redis Streams orqali navbat. Har bir nomlangan navbat - alohida stream,
+// workerlar bitta consumer group dan o'qiydi. Xabardagi task ClaimTaskByID orqali
+// bazada band qilinadi: task allaqachon olingan, bekor qilingan yoki o'chirilgan
+// bo'lsa xabar shunchaki tasdiqlanadi, shuning uchun takroriy xabarlar zararsiz.
+//
+// Qulagan worker olgan xabarlar pending ro'yxatida qoladi va lease muddatidan keyin
+// XCLAIM bilan boshqa workerga o'tkaziladi. Xabari umuman yo'qolgan tasklar
+// (masalan Redis qayta ishga tushgan) resync oralig'ida bazadan topilib qayta yuboriladi.
+type redisQueue struct {
+	db      storage.IStorage
+	stream  *redisstore.TaskStream
+	logger  *slog.Logger
+	minIdle time.Duration // Shuncha vaqt tasdiqlanmagan xabar boshqa workerga o'tkaziladi
+	resync  time.Duration
+	queues  []string // Shu jarayon xizmat qiladigan navbatlar (resync uchun)
+
+	mu        sync.Mutex
+	lastClaim map[string]time.Time // navbat -> oxirgi XCLAIM vaqti
+	lastSync  time.Time
+}
+
+func newRedisQueue(pdb storage.IStorage, stream *redisstore.TaskStream, logger *slog.Logger, cfg config.WorkerConfig) *redisQueue {
+	minIdle := cfg.Lease
+	if minIdle <= 0 {
+		minIdle = time.Minute
+	}
+	resync := cfg.QueueResync
+	if resync <= 0 {
+		resync = 5 * time.Minute
+	}
+
+	queues := make([]string, 0, len(cfg.Queues))
+	for _, qc := range cfg.Queues {
+		queues = append(queues, qc.Name)
+	}
+
+	return &redisQueue{
+		db:        pdb,
+		stream:    stream,
+		logger:    logger,
+		minIdle:   minIdle,
+		resync:    resync,
+		queues:    queues,
+		lastClaim: make(map[string]time.Time),
+		lastSync:  time.Now(),
+	}
+}
+
+func (q *redisQueue) Push(ctx context.Context, task *db.Task) error {
+	queue := task.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	return q.stream.Add(ctx, queue, task.ID, task.Type)
+}
+
+func (q *redisQueue) Pop(ctx context.Context, opts storage.ClaimOptions) (*Delivery, error) {
+	q.resyncStale(ctx)
+
+	scanned := 0
+	for _, queue := range opts.Queues {
+		// Avval qulagan workerlardan qolgan xabarlar. Bittadan olinadi: bir nechtasi
+		// olinsa, birinchisi bajarilayotganda qolganlari shu consumerda yana minIdle kutardi.
+		if q.claimDue(queue) {
+			for scanned < redisScanLimit {
+				msgs, err := q.stream.ClaimIdle(ctx, queue, opts.WorkerID, q.minIdle, 1)
+				if err != nil {
+					return nil, err
+				}
+				if len(msgs) == 0 {
+					break
+				}
+				q.logger.Warn("Tasdiqlanmagan xabar boshqa workerga o'tkazildi", "queue", queue, "task_id", msgs[0].TaskID)
+				scanned++
+				d, err := q.deliver(ctx, &msgs[0], opts)
+				if d != nil || err != nil {
+					return d, err
+				}
+			}
+		}
+
+		for scanned < redisScanLimit {
+			msg, err := q.stream.Read(ctx, queue, opts.WorkerID)
+			if err != nil {
+				return nil, err
+			}
+			if msg == nil {
+				break
+			}
+			scanned++
+			d, err := q.deliver(ctx, msg, opts)
+			if d != nil || err != nil {
+				return d, err
+			}
+		}
+	}
+	return nil, storage.ErrNoTask
+}
+
+// deliver - xabardagi taskni bazada band qilish. Band qilib bo'lmasa (nil, nil).
+func (q *redisQueue) deliver(ctx context.Context, msg *redisstore.StreamMessage, opts storage.ClaimOptions) (*Delivery, error) {
+	// Cheklovga yetgan tur: xabar navbat oxiriga o'tkaziladi, keyinroq olinadi
+	if msg.TaskID != "" && slices.Contains(opts.ExcludeTypes, msg.TaskType) {
+		return nil, q.postpone(ctx, msg)
+	}
+
+	if msg.TaskID != "" {
+		task, err := q.db.Task().ClaimTaskByID(ctx, msg.TaskID, opts)
+		if err == nil {
+			return &Delivery{Task: task, msg: msg}, nil
+		}
+		if errors.Is(err, storage.ErrTypeLimited) {
+			// Boshqa instancelardagi workerlar shu turning barcha joylarini egallagan
+			return nil, q.postpone(ctx, msg)
+		}
+		if !errors.Is(err, storage.ErrNoTask) {
+			// Xabar pending ro'yxatida qoladi va keyinroq qayta olinadi
+			return nil, err
+		}
+	}
+
+	// Task allaqachon olingan, tayyor emas yoki yo'q: xabar endi kerak emas
+	return nil, q.stream.Ack(ctx, *msg)
+}
+
+// postpone - xabarni navbat oxiriga o'tkazish
+func (q *redisQueue) postpone(ctx context.Context, msg *redisstore.StreamMessage) error {
+	if err := q.stream.Add(ctx, msg.Queue, msg.TaskID, msg.TaskType); err != nil {
+		return err
+	}
+	return q.stream.Ack(ctx, *msg)
+}
+
+func (q *redisQueue) Ack(ctx context.Context, d *Delivery) error {
+	if d.msg == nil {
+		return nil
+	}
+	return q.stream.Ack(ctx, *d.msg)
+}
+
+// Leave - to'xtagan workerning consumerlarini o'chirish. Consumer group da consumerlar
+// o'z-o'zidan o'chmaydi va har bir jarayon yangi instanceID bilan ishga tushadi, shuning
+// uchun o'chirilmasa ro'yxat cheksiz o'sadi. Tasdiqlanmagan xabari bor consumer
+// qoldiriladi: u xabarlar XCLAIM bilan boshqa workerga o'tishi kerak.
+func (q *redisQueue) Leave(ctx context.Context, consumer string) error {
+	for _, queue := range q.queues {
+		removed, err := q.stream.RemoveConsumer(ctx, queue, consumer)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			q.logger.Warn("Consumerda tasdiqlanmagan xabarlar bor, u o'chirilmadi", "queue", queue, "consumer", consumer)
+		}
+	}
+	return nil
+}
+
+// claimDue - navbatdagi tasdiqlanmagan xabarlarni tekshirish vaqti keldimi
+// (har bir navbat uchun shu jarayonda minIdle/2 da bir marta)
+func (q *redisQueue) claimDue(queue string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(q.lastClaim[queue]) < q.minIdle/2 {
+		return false
+	}
+	q.lastClaim[queue] = now
+	return true
+}
+
+// resyncStale - resync oralig'ida bir marta bazadagi uzoq kutgan tayyor tasklarni
+// qayta yuborish. TouchStaleReady ularning updated_at ini yangilaydi, shuning
+// uchun bir nechta instance bir taskni bir vaqtda qayta yubormaydi.
+func (q *redisQueue) resyncStale(ctx context.Context) {
+	q.mu.Lock()
+	if time.Since(q.lastSync) < q.resync {
+		q.mu.Unlock()
+		return
+	}
+	q.lastSync = time.Now()
+	q.mu.Unlock()
+
+	tasks, err := q.db.Task().TouchStaleReady(ctx, q.queues, q.resync, 1000)
+	if err != nil {
+		q.logger.Error("Kutib qolgan tasklarni qidirishda xato", "error", err)
+		return
+	}
+	for i := range tasks {
+		if err := q.Push(ctx, &tasks[i]); err != nil {
+			q.logger.Error("Taskni navbatga qayta yuborishda xato", "task_id", tasks[i].ID, "error", err)
+			return
+		}
+	}
+	if len(tasks) > 0 {
+		q.logger.Warn("Kutib qolgan tasklar navbatga qayta yuborildi", "count", len(tasks))
+	}
+}
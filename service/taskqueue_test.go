@@ -0,0 +1,431 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	redisstore "asynchronous/storage/redis"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeQueueTasks - bazadagi tasklar o'rniga: ready dagilar band qilinishi mumkin,
+// stale dagilar TouchStaleReady da qaytadi
+type fakeQueueTasks struct {
+	storage.ITaskStorage
+
+	mu           sync.Mutex
+	ready        map[string]db.Task
+	stale        []db.Task
+	staleQueues  []string
+	claimedCount int
+	limited      map[string]bool // Boshqa instancelarda max_concurrency ga yetgan turlar
+}
+
+func newFakeQueueTasks(tasks ...db.Task) *fakeQueueTasks {
+	f := &fakeQueueTasks{ready: make(map[string]db.Task)}
+	for _, task := range tasks {
+		f.ready[task.ID] = task
+	}
+	return f
+}
+
+func (f *fakeQueueTasks) ClaimTaskByID(ctx context.Context, taskID string, opts storage.ClaimOptions) (db.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	task, ok := f.ready[taskID]
+	if !ok {
+		return db.Task{}, storage.ErrNoTask
+	}
+	if f.limited[task.Type] {
+		return db.Task{}, storage.ErrTypeLimited
+	}
+	delete(f.ready, taskID)
+	f.claimedCount++
+
+	task.Status = "processing"
+	task.ClaimedBy = &opts.WorkerID
+	return task, nil
+}
+
+func (f *fakeQueueTasks) TouchStaleReady(ctx context.Context, queues []string, older time.Duration, limit int) ([]db.Task, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.staleQueues = queues
+	var list []db.Task
+	for _, task := range f.stale {
+		if slices.Contains(queues, task.Queue) {
+			list = append(list, task)
+		}
+	}
+	f.stale = nil
+	return list, nil
+}
+
+// setLimited - tur boshqa instancelarda max_concurrency ga yetgan yoki bo'shagan
+func (f *fakeQueueTasks) setLimited(taskType string, limited bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.limited == nil {
+		f.limited = make(map[string]bool)
+	}
+	f.limited[taskType] = limited
+}
+
+// setReady - task yana bajarishga tayyor (masalan reaper uni pending ga qaytardi)
+func (f *fakeQueueTasks) setReady(task db.Task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ready[task.ID] = task
+}
+
+type queueEnv struct {
+	mr    *miniredis.Miniredis
+	rdb   *redis.Client
+	tasks *fakeQueueTasks
+	cfg   config.WorkerConfig
+}
+
+func newQueueEnv(t *testing.T, tasks ...db.Task) *queueEnv {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return &queueEnv{
+		mr:    mr,
+		rdb:   rdb,
+		tasks: newFakeQueueTasks(tasks...),
+		cfg: config.WorkerConfig{
+			Lease:       time.Minute,
+			QueueResync: time.Hour,
+			Queues:      []config.QueueConfig{{Name: DefaultQueue, Weight: 1}},
+		},
+	}
+}
+
+// queue - alohida worker jarayoni kabi yangi redisQueue (umumiy Redis va baza bilan)
+func (e *queueEnv) queue() *redisQueue {
+	pdb := &fakeStorage{task: e.tasks}
+	return newRedisQueue(pdb, redisstore.NewTaskStream(e.rdb), discardLogger(), e.cfg)
+}
+
+func (e *queueEnv) pending(t *testing.T) []redis.XPendingExt {
+	t.Helper()
+
+	list, err := e.rdb.XPendingExt(context.Background(), &redis.XPendingExtArgs{
+		Stream: "tasks:stream:" + DefaultQueue,
+		Group:  "workers",
+		Start:  "-",
+		End:    "+",
+		Count:  100,
+	}).Result()
+	if err != nil {
+		t.Fatalf("XPENDING: %v", err)
+	}
+	return list
+}
+
+func claimOpts(worker string, exclude ...string) storage.ClaimOptions {
+	return storage.ClaimOptions{
+		Queues:       []string{DefaultQueue},
+		ExcludeTypes: exclude,
+		WorkerID:     worker,
+		Lease:        time.Minute,
+	}
+}
+
+func testTask(id, taskType string) db.Task {
+	return db.Task{ID: id, Type: taskType, Queue: DefaultQueue, Status: "pending"}
+}
+
+func TestRedisQueueAcksOnlyAfterSuccess(t *testing.T) {
+	ctx := context.Background()
+	task := testTask("task-1", "email")
+	env := newQueueEnv(t, task)
+	q := env.queue()
+
+	if err := q.Push(ctx, &task); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+	if d.Task.ID != task.ID || d.Task.Status != "processing" {
+		t.Fatalf("Pop = %s/%s, want %s/processing", d.Task.ID, d.Task.Status, task.ID)
+	}
+
+	// Task bajarilayotganda xabar tasdiqlanmagan: worker qulasa u boshqasiga o'tadi
+	if p := env.pending(t); len(p) != 1 || p[0].Consumer != "worker-a" {
+		t.Fatalf("pending while processing = %+v, want one entry of worker-a", p)
+	}
+
+	if err := q.Ack(ctx, d); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if p := env.pending(t); len(p) != 0 {
+		t.Fatalf("pending after Ack = %+v, want none", p)
+	}
+}
+
+func TestRedisQueueClaimsIdleMessagesOfDeadConsumer(t *testing.T) {
+	ctx := context.Background()
+	task := testTask("task-1", "email")
+	env := newQueueEnv(t, task)
+
+	start := time.Now()
+	env.mr.SetTime(start)
+
+	dead := env.queue()
+	if err := dead.Push(ctx, &task); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if _, err := dead.Pop(ctx, claimOpts("worker-a")); err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+
+	// worker-a qulab tushdi, reaper taskni bazada pending ga qaytardi
+	env.tasks.setReady(task)
+
+	// Lease hali tugamagan: xabar worker-a da qoladi
+	if d, err := env.queue().Pop(ctx, claimOpts("worker-b")); !errors.Is(err, storage.ErrNoTask) {
+		t.Fatalf("Pop before lease = %+v, %v; want ErrNoTask", d, err)
+	}
+
+	env.mr.SetTime(start.Add(2 * env.cfg.Lease))
+
+	d, err := env.queue().Pop(ctx, claimOpts("worker-c"))
+	if err != nil {
+		t.Fatalf("Pop after lease: %v", err)
+	}
+	if d.Task.ID != task.ID || *d.Task.ClaimedBy != "worker-c" {
+		t.Fatalf("Pop after lease = %s by %v, want %s by worker-c", d.Task.ID, *d.Task.ClaimedBy, task.ID)
+	}
+	if p := env.pending(t); len(p) != 1 || p[0].Consumer != "worker-c" {
+		t.Fatalf("pending after XCLAIM = %+v, want one entry of worker-c", p)
+	}
+}
+
+func TestRedisQueueClaimsIdleMessagesOneAtATime(t *testing.T) {
+	ctx := context.Background()
+	tasks := []db.Task{testTask("task-1", "email"), testTask("task-2", "email"), testTask("task-3", "email")}
+	env := newQueueEnv(t, tasks...)
+
+	start := time.Now()
+	env.mr.SetTime(start)
+
+	dead := env.queue()
+	for i := range tasks {
+		if err := dead.Push(ctx, &tasks[i]); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+	for range tasks {
+		if _, err := dead.Pop(ctx, claimOpts("worker-a")); err != nil {
+			t.Fatalf("Pop: %v", err)
+		}
+	}
+	for _, task := range tasks {
+		env.tasks.setReady(task)
+	}
+	env.mr.SetTime(start.Add(2 * env.cfg.Lease))
+
+	// worker-b faqat bajaradigan xabarini oladi: qolganlari worker-a da, idle vaqti saqlangan
+	if _, err := env.queue().Pop(ctx, claimOpts("worker-b")); err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+	owners := make(map[string]int)
+	for _, p := range env.pending(t) {
+		owners[p.Consumer]++
+	}
+	if owners["worker-b"] != 1 || owners["worker-a"] != 2 {
+		t.Fatalf("pending owners = %v, want worker-b: 1, worker-a: 2", owners)
+	}
+
+	// Boshqa worker qolganini darhol oladi, yana bir lease kutmasdan
+	if _, err := env.queue().Pop(ctx, claimOpts("worker-c")); err != nil {
+		t.Fatalf("Pop by worker-c = %v, want the next idle message", err)
+	}
+}
+
+func TestRedisQueueLeaveRemovesIdleConsumer(t *testing.T) {
+	ctx := context.Background()
+	task := testTask("task-1", "email")
+	env := newQueueEnv(t, task)
+	q := env.queue()
+
+	if err := q.Push(ctx, &task); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+
+	consumers := func() []string {
+		list, err := env.rdb.XInfoConsumers(ctx, "tasks:stream:"+DefaultQueue, "workers").Result()
+		if err != nil {
+			t.Fatalf("XINFO CONSUMERS: %v", err)
+		}
+		var names []string
+		for _, c := range list {
+			names = append(names, c.Name)
+		}
+		return names
+	}
+
+	// Tasdiqlanmagan xabar bor: consumer qoladi, xabar yo'qolmaydi
+	if err := q.Leave(ctx, "worker-a"); err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	if names := consumers(); !slices.Contains(names, "worker-a") || len(env.pending(t)) != 1 {
+		t.Fatalf("consumers = %v, pending %d; want worker-a kept with its message", names, len(env.pending(t)))
+	}
+
+	if err := q.Ack(ctx, d); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if err := q.Leave(ctx, "worker-a"); err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	if names := consumers(); slices.Contains(names, "worker-a") {
+		t.Fatalf("consumers after Leave = %v, want worker-a removed", names)
+	}
+
+	// Hali o'qilmagan navbat (stream yo'q) xato bermaydi
+	env.cfg.Queues = append(env.cfg.Queues, config.QueueConfig{Name: "reports", Weight: 1})
+	if err := env.queue().Leave(ctx, "worker-a"); err != nil {
+		t.Fatalf("Leave with missing stream: %v", err)
+	}
+}
+
+func TestRedisQueueRequeuesLimitedTypes(t *testing.T) {
+	ctx := context.Background()
+	task := testTask("task-1", "email")
+	env := newQueueEnv(t, task)
+	q := env.queue()
+
+	if err := q.Push(ctx, &task); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// "email" cheklovga yetgan: task olinmaydi, xabar navbat oxiriga qaytadi
+	if d, err := q.Pop(ctx, claimOpts("worker-a", "email")); !errors.Is(err, storage.ErrNoTask) {
+		t.Fatalf("Pop with email excluded = %+v, %v; want ErrNoTask", d, err)
+	}
+	if env.tasks.claimedCount != 0 {
+		t.Fatalf("limited task was claimed in the database")
+	}
+	if p := env.pending(t); len(p) != 0 {
+		t.Fatalf("pending after skipping = %+v, want none", p)
+	}
+
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop after limit lifted: %v", err)
+	}
+	if d.Task.ID != task.ID {
+		t.Fatalf("Pop = %s, want %s", d.Task.ID, task.ID)
+	}
+}
+
+func TestRedisQueueRequeuesTypesLimitedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	task := testTask("task-1", "email")
+	env := newQueueEnv(t, task)
+	q := env.queue()
+
+	if err := q.Push(ctx, &task); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// Shu jarayon cheklovga yetmagan, lekin bazadagi sanoq bo'yicha joy yo'q:
+	// xabar yo'qolmaydi, navbat oxiriga qaytadi
+	env.tasks.setLimited("email", true)
+	if d, err := q.Pop(ctx, claimOpts("worker-a")); !errors.Is(err, storage.ErrNoTask) {
+		t.Fatalf("Pop while limited = %+v, %v; want ErrNoTask", d, err)
+	}
+	if p := env.pending(t); len(p) != 0 {
+		t.Fatalf("pending after postponing = %+v, want none", p)
+	}
+
+	env.tasks.setLimited("email", false)
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop after a slot freed: %v", err)
+	}
+	if d.Task.ID != task.ID {
+		t.Fatalf("Pop = %s, want %s", d.Task.ID, task.ID)
+	}
+}
+
+func TestRedisQueueAcksStaleMessages(t *testing.T) {
+	ctx := context.Background()
+	finished := testTask("finished", "email") // Bazada allaqachon bajarilgan yoki bekor qilingan
+	ready := testTask("ready", "email")
+	env := newQueueEnv(t, ready)
+	q := env.queue()
+
+	// Bitta task uchun ikki xabar (masalan Stop va resync ikkalasi yuborgan)
+	for _, task := range []db.Task{finished, ready, ready} {
+		if err := q.Push(ctx, &task); err != nil {
+			t.Fatalf("Push: %v", err)
+		}
+	}
+
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+	if d.Task.ID != ready.ID {
+		t.Fatalf("Pop = %s, want %s", d.Task.ID, ready.ID)
+	}
+	if err := q.Ack(ctx, d); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	// Takroriy xabar: task allaqachon olingan, xabar shunchaki tasdiqlanadi
+	if d, err := q.Pop(ctx, claimOpts("worker-a")); !errors.Is(err, storage.ErrNoTask) {
+		t.Fatalf("second Pop = %+v, %v; want ErrNoTask", d, err)
+	}
+	if p := env.pending(t); len(p) != 0 {
+		t.Fatalf("stale messages left pending: %+v", p)
+	}
+	if env.tasks.claimedCount != 1 {
+		t.Fatalf("claimed %d times, want 1", env.tasks.claimedCount)
+	}
+}
+
+func TestRedisQueueResyncsTasksMissingFromStream(t *testing.T) {
+	ctx := context.Background()
+	lost := testTask("lost", "email") // Bazaga yozilgan, lekin xabari streamga yetmagan
+	env := newQueueEnv(t, lost)
+	env.tasks.stale = []db.Task{lost}
+	env.cfg.QueueResync = time.Millisecond
+
+	q := env.queue()
+	time.Sleep(2 * time.Millisecond)
+
+	d, err := q.Pop(ctx, claimOpts("worker-a"))
+	if err != nil {
+		t.Fatalf("Pop: %v", err)
+	}
+	if d.Task.ID != lost.ID {
+		t.Fatalf("Pop = %s, want %s", d.Task.ID, lost.ID)
+	}
+	if !slices.Equal(env.tasks.staleQueues, []string{DefaultQueue}) {
+		t.Fatalf("resync queues = %v, want only the queues this process serves", env.tasks.staleQueues)
+	}
+}
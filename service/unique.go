@@ -0,0 +1,76 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateTask - xuddi shunday task allaqachon navbatda yoki bajarilmoqda
+var ErrDuplicateTask = errors.New("xuddi shunday task allaqachon navbatda")
+
+// Unikal task takrorlanganda nima qilish
+const (
+	UniqueReject = "reject" // Yangi task rad etiladi (standart)
+	UniqueMerge  = "merge"  // Yangi task yaratilmaydi, mavjud task qaytariladi
+)
+
+// UniqueOptions - task unikalligi. Key bo'sh bo'lsa type + payload xeshi ishlatiladi.
+// Unikallik faqat pending va processing tasklar orasida tekshiriladi.
+type UniqueOptions struct {
+	Key        string
+	OnConflict string
+}
+
+// validateUniqueOptions - standart qiymatlarni qo'yish va tekshirish
+func validateUniqueOptions(opts *UniqueOptions) error {
+	if opts.OnConflict == "" {
+		opts.OnConflict = UniqueReject
+	}
+	if opts.OnConflict != UniqueReject && opts.OnConflict != UniqueMerge {
+		return fmt.Errorf("%w: unique.on_conflict %q yoki %q bo'lishi kerak", ErrInvalidTask, UniqueReject, UniqueMerge)
+	}
+	if len(opts.Key) > 255 {
+		return fmt.Errorf("%w: unique.key 255 belgidan oshmasligi kerak", ErrInvalidTask)
+	}
+	return nil
+}
+
+// uniqueKey - task turi va foydalanuvchi kaliti (yoki payload) dan sha256 xesh.
+// Payload kanonik ko'rinishga keltiriladi: kalitlar tartibi va bo'shliqlar ahamiyatsiz.
+func uniqueKey(taskType string, payload json.RawMessage, key string) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(taskType))
+	h.Write([]byte{0})
+
+	if key != "" {
+		h.Write([]byte("key:"))
+		h.Write([]byte(key))
+	} else {
+		canonical, err := canonicalJSON(payload)
+		if err != nil {
+			return "", fmt.Errorf("%w: payload JSON emas: %v", ErrInvalidTask, err)
+		}
+		h.Write([]byte("payload:"))
+		h.Write(canonical)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalJSON - JSON ni qayta kodlash: encoding/json obyekt kalitlarini saralaydi
+func canonicalJSON(raw json.RawMessage) ([]byte, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return []byte("null"), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber() // Katta sonlar float64 ga aylanib, xesh o'zgarib qolmasin
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
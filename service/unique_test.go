@@ -0,0 +1,46 @@
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// conflictTasks - pending ga qaytarishda idx_tasks_unique_key buziladi
+type conflictTasks struct {
+	storage.ITaskStorage
+}
+
+func (conflictTasks) GetTask(ctx context.Context, id string) (db.Task, error) {
+	key := "hash"
+	return db.Task{ID: id, Status: "failed", UniqueKey: &key}, nil
+}
+
+func (conflictTasks) UpdateTask(ctx context.Context, task db.Task) error {
+	return fmt.Errorf("%w: pq: duplicate key value", storage.ErrUniqueConflict)
+}
+
+type conflictDeadLetters struct {
+	storage.IDeadLetterStorage
+}
+
+func (conflictDeadLetters) Requeue(ctx context.Context, filter storage.DeadLetterFilter) ([]db.Task, error) {
+	return nil, fmt.Errorf("dead-letter tasklarni qayta navbatga qo'yishda xato: %w", storage.ErrUniqueConflict)
+}
+
+func TestRequeueUniqueConflictIsDuplicate(t *testing.T) {
+	s := &TaskService{
+		storage: &fakeStorage{task: conflictTasks{}, deadLetter: conflictDeadLetters{}},
+		logger:  discardLogger(),
+	}
+
+	if _, err := s.RetryTask(context.Background(), "task-1"); !errors.Is(err, ErrDuplicateTask) {
+		t.Fatalf("RetryTask = %v, want ErrDuplicateTask", err)
+	}
+	if _, err := s.RequeueDeadLetters(context.Background(), storage.DeadLetterFilter{Type: "email"}); !errors.Is(err, ErrDuplicateTask) {
+		t.Fatalf("RequeueDeadLetters = %v, want ErrDuplicateTask", err)
+	}
+}
@@ -0,0 +1,448 @@
+// service/webhook_service.go
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrInvalidWebhook - webhook ma'lumotlari noto'g'ri
+var ErrInvalidWebhook = errors.New("webhook ma'lumotlari noto'g'ri")
+
+// webhookEvents - obuna bo'lish mumkin bo'lgan hodisalar: "task." + task holati
+var webhookEvents = map[string]bool{
+	"task.pending":    true, // Qayta urinish yoki qo'lda qayta navbatga qo'yish
+	"task.processing": true,
+	"task.completed":  true,
+	"task.failed":     true,
+	"task.cancelled":  true,
+	"task.skipped":    true,
+}
+
+// webhookRetryPolicy - muvaffaqiyatsiz yuborishlar orasidagi kutish: 30s, 60s, 120s... 1 soatgacha
+var webhookRetryPolicy = db.RetryPolicy{
+	Strategy: db.RetryExponential,
+	Delay:    15,
+	MaxDelay: 3600,
+	Jitter:   0.2,
+}
+
+const (
+	webhookBatchSize     = 20   // Bir tekshiruvda olinadigan yuborishlar
+	webhookMaxError      = 1000 // last_error ga yoziladigan javob/xato uzunligi
+	webhookMinSecretSize = 16
+)
+
+// Webhook so'rovi headerlari
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// WebhookPayload - webhook so'rovi tanasi
+type WebhookPayload struct {
+	Event string       `json:"event"`
+	Data  db.TaskEvent `json:"data"`
+}
+
+// BatchCompletedEvent - batch on_complete webhooki hodisasi
+const BatchCompletedEvent = "batch.completed"
+
+// BatchWebhookPayload - batch on_complete webhooki so'rovi tanasi
+type BatchWebhookPayload struct {
+	Event string         `json:"event"`
+	Data  db.BatchStatus `json:"data"`
+}
+
+// SignWebhook - so'rov imzosi: "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body)).
+// Qabul qiluvchi shu qiymatni X-Webhook-Signature bilan solishtiradi va eski
+// timestamp li so'rovlarni rad etadi.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// enqueueWebhooks - task yaratuvchisi va ijrochisining shu hodisaga obuna bo'lgan
+// webhooklari uchun yuborishlarni yaratish. Yuborishning o'zi WebhookService da.
+func enqueueWebhooks(pdb storage.IStorage, logger *slog.Logger, event db.TaskEvent) {
+	name := "task." + event.Status
+	if !webhookEvents[name] {
+		return
+	}
+
+	payload, err := json.Marshal(WebhookPayload{Event: name, Data: event})
+	if err != nil {
+		logger.Error("Webhook hodisasini kodlashda xato", "task_id", event.TaskID, "error", err)
+		return
+	}
+
+	users := []string{event.CreatorID}
+	if event.UserID != "" && event.UserID != event.CreatorID {
+		users = append(users, event.UserID)
+	}
+	if _, err := pdb.Webhook().EnqueueDeliveries(context.Background(), users, event.TaskID, name, payload); err != nil {
+		logger.Error("Webhook yuborishlarini yaratishda xato", "task_id", event.TaskID, "error", err)
+	}
+}
+
+// errBlockedAddress - webhook manzili ichki tarmoqqa tegishli
+var errBlockedAddress = errors.New("ichki tarmoq manziliga webhook yuborilmaydi")
+
+// blockedIP - webhook yuborib bo'lmaydigan manzillar: loopback, private, link-local
+// (jumladan bulut metadata servisi 169.254.169.254), CGNAT, multicast va 0.0.0.0
+func blockedIP(ip net.IP) bool {
+	return ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace - 100.64.0.0/10 (RFC 6598), provayder ichki tarmog'i
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// newWebhookClient - HTTP klient. Tekshiruv ulanish paytida, DNS hal qilingandan keyin
+// bajariladi: yaratishda tashqi manzilga hal bo'lgan nom keyin ichki manzilga
+// o'zgartirilsa (DNS rebinding) yoki redirect ichki manzilga yo'naltirsa ham so'rov ketmaydi.
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedIP(ip) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, host)
+			}
+			return nil
+		}
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Proxy ishlatilmaydi: aks holda tekshiruv proxy manziliga qo'llanadi
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// WebhookService - webhooklarni boshqaradi va vaqti kelgan yuborishlarni yuboradi
+type WebhookService struct {
+	storage      storage.IStorage
+	logger       *slog.Logger
+	client       *http.Client
+	allowPrivate bool
+	resolver     *net.Resolver
+	interval     time.Duration
+	maxAttempts  int
+	lease        time.Duration // Olingan yuborish shu vaqt boshqa instancega berilmaydi
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewWebhookService - yangi WebhookService yaratish
+func NewWebhookService(pdb storage.IStorage, logger *slog.Logger, cfg config.WebhookConfig) *WebhookService {
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 8
+	}
+
+	return &WebhookService{
+		storage:      pdb,
+		logger:       logger,
+		client:       newWebhookClient(timeout, cfg.AllowPrivate),
+		allowPrivate: cfg.AllowPrivate,
+		resolver:     net.DefaultResolver,
+		interval:     interval,
+		maxAttempts:  maxAttempts,
+		lease:        timeout + 30*time.Second,
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start - vaqti kelgan yuborishlarni davriy yuborishni boshlash
+func (s *WebhookService) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			// Navbat bo'shaguncha yuborish
+			for s.deliverDue() == webhookBatchSize {
+				select {
+				case <-s.stop:
+					return
+				default:
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop - yangi yuborishlarni olishni to'xtatish va boshlanganlarini kutish
+func (s *WebhookService) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// deliverDue - vaqti kelgan yuborishlarni parallel yuborish; olinganlar sonini qaytaradi
+func (s *WebhookService) deliverDue() int {
+	deliveries, err := s.storage.Webhook().ClaimDeliveries(context.Background(), webhookBatchSize, s.lease)
+	if err != nil {
+		s.logger.Error("Webhook yuborishlarini olishda xato", "error", err)
+		return 0
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(d *db.WebhookDelivery) {
+			defer wg.Done()
+			s.deliver(d)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries)
+}
+
+// deliver - bitta urinish: natija bo'yicha yuborish yakunlanadi yoki keyinroqqa qoldiriladi
+func (s *WebhookService) deliver(d *db.WebhookDelivery) {
+	code, err := s.send(d)
+	d.Attempts++
+	d.LastStatusCode = nil
+	d.LastError = nil
+	if code != 0 {
+		d.LastStatusCode = &code
+	}
+
+	switch {
+	case err == nil:
+		now := time.Now()
+		d.Status = db.DeliverySucceeded
+		d.DeliveredAt = &now
+	case d.Attempts >= s.maxAttempts:
+		msg := err.Error()
+		d.LastError = &msg
+		d.Status = db.DeliveryFailed
+		s.logger.Error("Webhook yuborilmadi, urinishlar tugadi",
+			"delivery_id", d.ID,
+			"webhook_id", d.WebhookID,
+			"attempts", d.Attempts,
+			"error", msg,
+		)
+	default:
+		msg := err.Error()
+		d.LastError = &msg
+		d.NextAttemptAt = time.Now().Add(retryDelay(&webhookRetryPolicy, d.Attempts))
+		s.logger.Warn("Webhook yuborilmadi, qayta urinish rejalashtirildi",
+			"delivery_id", d.ID,
+			"webhook_id", d.WebhookID,
+			"attempt", d.Attempts,
+			"next_attempt_at", d.NextAttemptAt,
+			"error", msg,
+		)
+	}
+
+	if err := s.storage.Webhook().FinishAttempt(context.Background(), *d); err != nil {
+		s.logger.Error("Webhook urinishini yozishda xato", "delivery_id", d.ID, "error", err)
+	}
+}
+
+// send - imzolangan POST so'rov. 2xx bo'lmagan javob ham xato hisoblanadi.
+func (s *WebhookService) send(d *db.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("so'rov yaratishda xato: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "asynchronous-webhook/1.0")
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, d.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(d.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookMaxError))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("javob %d: %s", resp.StatusCode, body)
+	}
+	return resp.StatusCode, nil
+}
+
+// validateWebhook - URL va hodisalarni tekshirish, secret bo'lmasa yaratish
+func (s *WebhookService) validateWebhook(ctx context.Context, w *db.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return fmt.Errorf("%w: url http:// yoki https:// bilan boshlanadigan to'liq manzil bo'lishi kerak", ErrInvalidWebhook)
+	}
+	if err := s.checkHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(w.Events))
+	events := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		if !webhookEvents[e] {
+			return fmt.Errorf("%w: noma'lum hodisa: %s", ErrInvalidWebhook, e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			events = append(events, e)
+		}
+	}
+	w.Events = events
+
+	if w.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return err
+		}
+		w.Secret = secret
+	}
+	if len(w.Secret) < webhookMinSecretSize {
+		return fmt.Errorf("%w: secret kamida %d belgi bo'lishi kerak", ErrInvalidWebhook, webhookMinSecretSize)
+	}
+	return nil
+}
+
+// checkHost - webhook hosti ichki tarmoq manziliga hal bo'lmasligini tekshirish.
+// Nom keyinroq boshqa manzilga o'zgarishi mumkin, shuning uchun yuborishda ham
+// tekshiriladi (newWebhookClient).
+func (s *WebhookService) checkHost(ctx context.Context, host string) error {
+	if s.allowPrivate {
+		return nil
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if blockedIP(ip) {
+			return fmt.Errorf("%w: %s", ErrInvalidWebhook, errBlockedAddress)
+		}
+		return nil
+	}
+
+	addrs, err := s.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("%w: %s manzili topilmadi", ErrInvalidWebhook, host)
+	}
+	for _, a := range addrs {
+		if blockedIP(a.IP) {
+			return fmt.Errorf("%w: %s", ErrInvalidWebhook, errBlockedAddress)
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret - so'rovlarni imzolash uchun tasodifiy kalit
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("secret yaratishda xato: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateWebhook - webhook yaratish. Secret faqat shu javobda qaytariladi.
+func (s *WebhookService) CreateWebhook(ctx context.Context, w db.Webhook) (*db.Webhook, error) {
+	if err := s.validateWebhook(ctx, &w); err != nil {
+		return nil, err
+	}
+
+	created, err := s.storage.Webhook().CreateWebhook(ctx, w)
+	if err != nil {
+		s.logger.Error("Webhook yaratishda xato", "error", err)
+		return nil, err
+	}
+	created.Secret = w.Secret
+
+	s.logger.Info("Webhook yaratildi", "webhook_id", created.ID, "user_id", created.UserID, "events", created.Events)
+	return &created, nil
+}
+
+// GetWebhook - webhookni ID bo'yicha olish
+func (s *WebhookService) GetWebhook(ctx context.Context, id string) (*db.Webhook, error) {
+	w, err := s.storage.Webhook().GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWebhooks - foydalanuvchi webhooklari; userID bo'sh bo'lsa barchasi
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID string) ([]db.Webhook, error) {
+	return s.storage.Webhook().ListWebhooks(ctx, userID)
+}
+
+// DeleteWebhook - webhook va uning yuborishlar tarixini o'chirish
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id string) error {
+	if err := s.storage.Webhook().DeleteWebhook(ctx, id); err != nil {
+		if !errors.Is(err, storage.ErrWebhookNotFound) {
+			s.logger.Error("Webhookni o'chirishda xato", "webhook_id", id, "error", err)
+		}
+		return err
+	}
+
+	s.logger.Info("Webhook o'chirildi", "webhook_id", id)
+	return nil
+}
+
+// ListDeliveries - webhook yuborishlari tarixi (yangilari birinchi)
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]db.WebhookDelivery, error) {
+	return s.storage.Webhook().ListDeliveries(ctx, webhookID, limit, offset)
+}
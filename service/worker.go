@@ -0,0 +1,721 @@
+// service/worker_pool.go
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTaskTimeout - task belgilangan vaqt ichida tugamadi
+var ErrTaskTimeout = errors.New("task bajarilish vaqti tugadi")
+
+// ErrTaskCancelled - task bajarilayotganda bekor qilindi.
+// Handler buni context.Cause(ctx) orqali ko'ra oladi.
+var ErrTaskCancelled = errors.New("task bekor qilindi")
+
+// WorkerPool - tasklarni bajaruvchi ishchilar pooli.
+// Workerlar tasklarni TaskQueue orqali (tasks jadvalidan yoki Redis Streams dan) band qilib oladi.
+type WorkerPool struct {
+	db           storage.IStorage
+	logger       *slog.Logger
+	workerCount  int                  // Og'irlik bo'yicha barcha navbatlarga xizmat qiluvchi workerlar
+	queues       []config.QueueConfig // Shu jarayon xizmat qiladigan navbatlar
+	weighted     *weightedQueues      // Umumiy workerlar uchun navbatlar tartibi
+	pollInterval time.Duration
+	claimOpts    storage.ClaimOptions
+	queue        TaskQueue     // Tayyor tasklarni workerlarga yetkazuvchi navbat
+	wakeup       chan struct{} // Yangi task paydo bo'lganda workerlarni uyg'otish
+	handlers     *handlerRegistry
+	scheduler    *Scheduler // Vaqti kelmagan tasklarni ushlab turadi
+	reaper       *Reaper    // Lease muddati o'tgan tasklarni qaytaradi
+	instanceID   string     // Shu jarayonning noyob nomi (claimed_by uchun)
+	lease        time.Duration
+	taskTimeout  time.Duration // Task o'z timeouti bo'lmasa ishlatiladi (0 - chegarasiz)
+	finishHooks  []func(task *db.Task)
+	limiter      *typeLimiter  // Task turlari bo'yicha cheklovlar
+	limitsReload time.Duration // Cheklovlar va to'xtatilgan navbatlarni qayta yuklash oralig'i
+
+	ctx      context.Context    // Bajarilayotgan tasklar uchun asosiy kontekst
+	cancel   context.CancelFunc // Stop muddati tugaganda handlerlarni to'xtatadi
+	stopping chan struct{}      // Yopilgach workerlar yangi task olmaydi
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	mu           sync.Mutex
+	running      map[string]*runningTask // task ID -> bajarilayotgan task
+	workers      map[int]*workerInfo     // worker ID -> ishlayotgan worker
+	lastWorkerID int
+	started      bool            // Start chaqirilgan: faqat API instanceda workerlar yo'q
+	paused       map[string]bool // To'xtatilgan navbatlar
+
+	commands       <-chan struct{} // Admin buyrug'i yozilgani haqida xabarlar (NOTIFY)
+	lastCommand    int64           // Bajarilgan oxirgi buyruq ID si
+	commandsLoaded bool            // lastCommand bazadan o'qilgan
+}
+
+// runningTask - hozir bajarilayotgan task haqida ma'lumot
+type runningTask struct {
+	task     *db.Task
+	worker   string // Taskni band qilgan worker nomi (claimed_by)
+	cancel   context.CancelCauseFunc
+	released string // Bo'sh bo'lmasa task endi bu workerga tegishli emas (urinish natijasi): natijasi bazaga yozilmaydi
+}
+
+// newInstanceID - jarayon uchun noyob nom: host-pid-tasodifiy
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
+
+// NewWorkerPool - yangi WorkerPool yaratish
+func NewWorkerPool(
+	db storage.IStorage,
+	logger *slog.Logger,
+	cfg config.WorkerConfig,
+	queue TaskQueue,
+	handlers *handlerRegistry,
+) *WorkerPool {
+	pollInterval := cfg.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 2 * time.Second
+	}
+	lease := cfg.Lease
+	if lease <= 0 {
+		lease = time.Minute
+	}
+	limitsReload := cfg.LimitsReload
+	if limitsReload <= 0 {
+		limitsReload = 30 * time.Second
+	}
+
+	wp := &WorkerPool{
+		db:           db,
+		logger:       logger,
+		workerCount:  cfg.WorkerCount,
+		queues:       cfg.Queues,
+		weighted:     newWeightedQueues(cfg.Queues),
+		pollInterval: pollInterval,
+		claimOpts:    storage.ClaimOptions{PriorityAging: cfg.PriorityAging, Lease: lease},
+		queue:        queue,
+		instanceID:   newInstanceID(),
+		lease:        lease,
+		taskTimeout:  cfg.TaskTimeout,
+		limiter:      newTypeLimiter(),
+		limitsReload: limitsReload,
+		wakeup:       make(chan struct{}, max(totalWorkers(cfg), 1)),
+		handlers:     handlers,
+		stopping:     make(chan struct{}),
+		running:      make(map[string]*runningTask),
+		workers:      make(map[int]*workerInfo),
+		paused:       make(map[string]bool),
+	}
+	wp.ctx, wp.cancel = context.WithCancel(context.Background())
+	wp.scheduler = NewScheduler(db, logger, wp.dispatch, cfg.ScheduleReload)
+	wp.reaper = NewReaper(db, logger, cfg.ReaperInterval, wp.publishEvent, wp.Enqueue, wp.finished)
+
+	return wp
+}
+
+// Start - workerlarni ishga tushirish
+func (wp *WorkerPool) Start() {
+	wp.scheduler.Start()
+	wp.reaper.Start()
+	wp.loadLimits()
+	wp.loadPausedQueues()
+	wp.applyCommands()
+
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.started = true
+
+	if wp.weighted.empty() {
+		if wp.workerCount > 0 {
+			wp.logger.Warn("Og'irligi 0 dan katta navbat yo'q, umumiy workerlar ishga tushirilmadi", "worker_count", wp.workerCount)
+			wp.workerCount = 0
+		}
+	} else {
+		for i := 0; i < wp.workerCount; i++ {
+			wp.spawnLocked("")
+		}
+	}
+
+	// Alohida workerlar faqat o'z navbatidan task oladi
+	for _, q := range wp.queues {
+		for i := 0; i < q.Workers; i++ {
+			wp.spawnLocked(q.Name)
+		}
+	}
+
+	go wp.reloadControls()
+	go wp.reportStates()
+
+	wp.logger.Info("Worker pool ishga tushdi", "workers", len(wp.workers), "queues", wp.queues)
+}
+
+// totalWorkers - umumiy va alohida workerlar soni
+func totalWorkers(cfg config.WorkerConfig) int {
+	n := cfg.WorkerCount
+	for _, q := range cfg.Queues {
+		n += q.Workers
+	}
+	return n
+}
+
+// Stop - workerlarga yangi task berishni to'xtatadi va bajarilayotgan tasklarni
+// ctx tugaguncha kutadi. Muddat tugasa ham yakunlanmagan tasklar "pending" ga
+// qaytariladi, shunda ular keyingi ishga tushishda qayta olinadi.
+func (wp *WorkerPool) Stop(ctx context.Context) error {
+	wp.stopOnce.Do(func() {
+		// mu ostida: Scale stopping yopilgandan keyin yangi worker qo'sha olmaydi
+		wp.mu.Lock()
+		close(wp.stopping)
+		wp.mu.Unlock()
+		wp.reaper.Stop()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		wp.logger.Info("Barcha workerlar to'xtadi")
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Muddat tugadi: avval tasklarni qaytarilgan deb belgilash, keyin handlerlarga
+	// to'xtash signalini berish. Aks holda signaldan darhol qaytgan handlerning xatosi
+	// oddiy xato sifatida yozilib, qayta urinish sarflanardi.
+	wp.mu.Lock()
+	var released []*runningTask
+	for _, rt := range wp.running {
+		rt.released = db.AttemptReleased
+		released = append(released, rt)
+	}
+	wp.mu.Unlock()
+
+	wp.cancel()
+
+	for _, rt := range released {
+		if wp.releaseTask(rt.task, rt.worker) {
+			wp.logger.Warn("Yakunlanmagan task navbatga qaytarildi", "task_id", rt.task.ID)
+		}
+	}
+
+	return fmt.Errorf("workerlar muddatida to'xtamadi: %d ta task navbatga qaytarildi", len(released))
+}
+
+// releaseTask - workerName band qilgan, lekin bajarilmagan taskni "pending" ga qaytarib
+// e'lon qilish va navbatga yuborish. Task endi bu workerga tegishli bo'lmasa (reaper
+// qaytargan va boshqa worker olgan) tegilmaydi. Qaytarilmasa false.
+func (wp *WorkerPool) releaseTask(task *db.Task, workerName string) bool {
+	if err := wp.db.Task().ReleaseTask(context.Background(), task.ID, workerName); err != nil {
+		if errors.Is(err, storage.ErrTaskNotOwned) {
+			wp.logger.Warn("Task boshqa workerga o'tgan, navbatga qaytarilmadi", "task_id", task.ID, "worker", workerName)
+			return false
+		}
+		wp.logger.Error("Taskni navbatga qaytarishda xato", "task_id", task.ID, "error", err)
+		return false
+	}
+
+	task.Status = "pending"
+	task.ClaimedBy = nil
+	task.LeaseExpiresAt = nil
+	task.UpdatedAt = time.Now()
+	wp.publishEvent(task)
+	wp.dispatch(task)
+	return true
+}
+
+// Enqueue - pending holatdagi taskni bajarishga uzatish:
+// vaqti kelgan bo'lsa navbatga yuboradi, aks holda schedulerga topshiradi
+func (wp *WorkerPool) Enqueue(task *db.Task) {
+	if at := readyAt(task); at.After(time.Now()) {
+		wp.scheduler.Schedule(task, at)
+		return
+	}
+	wp.dispatch(task)
+}
+
+// dispatch - tayyor task haqida navbatga xabar berish va workerni uyg'otish.
+// Xabar yuborilmasa ham task bazada qoladi: Redis backendi uni resync da topadi.
+func (wp *WorkerPool) dispatch(task *db.Task) {
+	if err := wp.queue.Push(context.Background(), task); err != nil {
+		wp.logger.Error("Taskni navbatga yuborishda xato", "task_id", task.ID, "error", err)
+	}
+	wp.Notify()
+}
+
+// Notify - kutib turgan workerlardan birini uyg'otish (bloklanmaydi)
+func (wp *WorkerPool) Notify() {
+	select {
+	case wp.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// workerName - claimed_by ga yoziladigan worker nomi
+func (wp *WorkerPool) workerName(workerID int) string {
+	return fmt.Sprintf("%s-%d", wp.instanceID, workerID)
+}
+
+// worker - har bir individual ishchi. Pool to'xtatilganda yoki Scale uni
+// kamaytirganda joriy taskni tugatib chiqadi.
+func (wp *WorkerPool) worker(w *workerInfo) {
+	defer wp.wg.Done()
+	defer wp.removeWorker(w)
+	workerID := w.id
+	wp.logger.Info("Worker ishga tushdi", "worker_id", workerID, "queue", w.queue)
+
+	opts := wp.claimOpts
+	opts.WorkerID = wp.workerName(workerID)
+	defer func() {
+		if err := wp.queue.Leave(context.Background(), opts.WorkerID); err != nil {
+			wp.logger.Error("Worker navbatdan chiqarilmadi", "worker_id", workerID, "error", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-wp.stopping:
+			wp.logger.Info("Worker to'xtadi", "worker_id", workerID)
+			return
+		case <-w.quit:
+			wp.logger.Info("Worker pool kichraytirildi, worker to'xtadi", "worker_id", workerID)
+			return
+		default:
+		}
+
+		// Barcha navbatlari to'xtatilgan bo'lsa kutish: bo'sh ro'yxat "barcha navbatlar" degani
+		opts.Queues = wp.workerQueues(w)
+		if len(opts.Queues) == 0 {
+			wp.wait(w.quit, 0)
+			continue
+		}
+
+		// Cheklovga yetgan turlar navbatdan olinmaydi
+		var retryIn time.Duration
+		opts.ExcludeTypes, retryIn = wp.limiter.blocked()
+
+		d, err := wp.queue.Pop(context.Background(), opts)
+		if err != nil {
+			if !errors.Is(err, storage.ErrNoTask) {
+				wp.logger.Error("Navbatdan task olishda xato", "worker_id", workerID, "error", err)
+			}
+			wp.wait(w.quit, retryIn)
+			continue
+		}
+		task := d.Task
+
+		if !wp.limiter.acquire(task.Type) {
+			// Boshqa worker shu turdagi oxirgi joyni oldinroq egalladi: taskni qaytarish
+			wp.releaseTask(&task, opts.WorkerID)
+			wp.ack(d)
+			continue
+		}
+
+		wp.setWorkerTask(w, &task)
+		wp.processTask(workerID, &task)
+		wp.setWorkerTask(w, nil)
+		wp.ack(d)
+		if wp.limiter.release(task.Type) {
+			// Cheklangan turda joy bo'shadi: kutayotgan worker shu turni olishi mumkin
+			wp.Notify()
+		}
+	}
+}
+
+// ack - navbatdan olingan xabar bilan ish tugaganini tasdiqlash
+func (wp *WorkerPool) ack(d *Delivery) {
+	if err := wp.queue.Ack(context.Background(), d); err != nil {
+		wp.logger.Error("Navbat xabarini tasdiqlashda xato", "task_id", d.Task.ID, "error", err)
+	}
+}
+
+// wait - yangi task haqida xabar, keyingi tekshiruv vaqti yoki to'xtash signalini kutish.
+// d > 0 bo'lsa (cheklangan turga token paydo bo'lishi) kutish shu vaqtdan oshmaydi.
+func (wp *WorkerPool) wait(quit <-chan struct{}, d time.Duration) {
+	if d <= 0 || d > wp.pollInterval {
+		d = wp.pollInterval
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-wp.wakeup:
+	case <-timer.C:
+	case <-wp.stopping:
+	case <-quit:
+	}
+}
+
+// track - taskni bajarilayotganlar ro'yxatiga qo'shish va uning kontekstini qaytarish
+func (wp *WorkerPool) track(task *db.Task, workerName string) context.Context {
+	ctx, cancel := context.WithCancelCause(wp.ctx)
+
+	wp.mu.Lock()
+	wp.running[task.ID] = &runningTask{task: task, worker: workerName, cancel: cancel}
+	wp.mu.Unlock()
+
+	return ctx
+}
+
+// untrack - taskni ro'yxatdan olib tashlash. Task Stop paytida navbatga qaytarilgan yoki
+// leasi yo'qolgan bo'lsa shu urinish natijasini qaytaradi: bunday task natijasini
+// bazaga yozish mumkin emas. Aks holda bo'sh satr qaytadi.
+func (wp *WorkerPool) untrack(taskID string) string {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	rt, ok := wp.running[taskID]
+	if !ok {
+		return ""
+	}
+	rt.cancel(nil)
+	delete(wp.running, taskID)
+	return rt.released
+}
+
+// Cancel - shu jarayonda bajarilayotgan task handleriga to'xtash signalini berish.
+// Task boshqa instanceda bajarilayotgan bo'lsa u yerda heartbeat buni aniqlaydi.
+func (wp *WorkerPool) Cancel(taskID string) bool {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	rt, ok := wp.running[taskID]
+	if !ok {
+		return false
+	}
+	rt.released = db.AttemptCancelled
+	rt.cancel(ErrTaskCancelled)
+	return true
+}
+
+// heartbeat - task bajarilayotganda leaseni muntazam uzaytirish. Lease yo'qolsa
+// (reaper qaytargan yoki task boshqa workerga o'tgan) task konteksti bekor qilinadi.
+func (wp *WorkerPool) heartbeat(ctx context.Context, task *db.Task, workerName string) {
+	ticker := time.NewTicker(wp.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		ok, err := wp.db.Task().ExtendLease(context.Background(), task.ID, workerName, wp.lease)
+		if err != nil {
+			wp.logger.Error("Leaseni uzaytirishda xato", "task_id", task.ID, "error", err)
+			continue
+		}
+		if !ok {
+			// Task bekor qilinganmi yoki reaper qaytarganmi
+			outcome, cause := db.AttemptLeaseLost, context.Canceled
+			if current, err := wp.db.Task().GetTask(context.Background(), task.ID); err == nil && current.Status == "cancelled" {
+				outcome, cause = db.AttemptCancelled, ErrTaskCancelled
+			}
+
+			wp.logger.Warn("Task leasi yo'qoldi, bajarish to'xtatiladi", "task_id", task.ID, "outcome", outcome)
+			wp.mu.Lock()
+			if rt, found := wp.running[task.ID]; found {
+				rt.released = outcome
+				rt.cancel(cause)
+			}
+			wp.mu.Unlock()
+			return
+		}
+	}
+}
+
+// processTask - taskni bajarish logikasi (task allaqachon "processing" holatida)
+func (wp *WorkerPool) processTask(workerID int, task *db.Task) {
+	wp.logger.Info("Task olindi", "worker_id", workerID, "task_id", task.ID)
+	name := wp.workerName(workerID)
+	wp.publishEvent(task)
+
+	// 1. Taskni bajarish (bajarilish davomida lease uzaytirib turiladi)
+	attempt := wp.startAttempt(task, name)
+	ctx := wp.track(task, name)
+	go wp.heartbeat(ctx, task, name)
+
+	err := wp.executeTaskLogic(ctx, task)
+	if released := wp.untrack(task.ID); released != "" {
+		wp.finishAttempt(&attempt, released, err)
+		wp.logger.Warn("Task navbatga qaytarilgan, natija yozilmaydi", "task_id", task.ID)
+		return
+	}
+
+	if err != nil {
+		outcome := db.AttemptFailed
+		if errors.Is(err, ErrTaskTimeout) {
+			outcome = db.AttemptTimedOut
+		}
+		wp.finishAttempt(&attempt, outcome, err)
+		wp.recordError(task, attempt.Attempt, err)
+		wp.handleTaskError(task, err)
+		return
+	}
+	wp.finishAttempt(&attempt, db.AttemptSucceeded, nil)
+
+	// 2. Muvaffaqiyatli yakunlash
+	task.NextRetryAt = nil
+	if err := wp.updateTaskStatus(task, "completed"); err != nil {
+		return
+	}
+	wp.finished(task)
+
+	// 3. Natijani saqlash
+	if err := wp.saveTaskResult(task); err != nil {
+		wp.logger.Error("Natijani saqlashda xato", "error", err)
+	}
+}
+
+// executeTaskLogic - taskni turiga mos handlerga uzatish. Handler kontekstni
+// kuzatmasa ham timeout yoki bekor qilinganda worker bo'shatiladi.
+func (wp *WorkerPool) executeTaskLogic(ctx context.Context, task *db.Task) error {
+	handler, ok := wp.handlers.get(task.Type)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTaskType, task.Type)
+	}
+
+	// Payloadni parse qilish
+	payload := map[string]interface{}{}
+	if len(task.Payload) > 0 && string(task.Payload) != "null" {
+		if err := json.Unmarshal(task.Payload, &payload); err != nil {
+			return fmt.Errorf("payloadni parse qilishda xato: %w", err)
+		}
+	}
+
+	timeout := wp.taskTimeout
+	if task.TimeoutSeconds > 0 {
+		timeout = time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, timeout, ErrTaskTimeout)
+		defer cancel()
+	}
+
+	wp.logger.Info("Task bajarilmoqda...", "task_id", task.ID, "type", task.Type, "timeout", timeout)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Handle(ctx, task, payload)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		wp.logger.Warn("Handler to'xtash signalini kutmadi, worker bo'shatildi", "task_id", task.ID)
+		err = context.Cause(ctx)
+	}
+
+	if err != nil && errors.Is(context.Cause(ctx), ErrTaskTimeout) {
+		return fmt.Errorf("%w (%s)", ErrTaskTimeout, timeout)
+	}
+	return err
+}
+
+// startAttempt - yangi urinishni task_attempts ga yozish. Yozib bo'lmasa ham task
+// bajarilaveradi: urinishlar tarixi faqat kuzatish uchun.
+func (wp *WorkerPool) startAttempt(task *db.Task, workerName string) db.TaskAttempt {
+	attempt := db.TaskAttempt{
+		TaskID:    task.ID,
+		Attempt:   task.Retries + 1,
+		WorkerID:  workerName,
+		StartedAt: time.Now(),
+	}
+
+	started, err := wp.db.TaskAttempt().StartAttempt(context.Background(), attempt)
+	if err != nil {
+		wp.logger.Error("Urinishni yozishda xato", "task_id", task.ID, "error", err)
+		return attempt
+	}
+	return started
+}
+
+// finishAttempt - urinish natijasini, davomiyligini va xatosini yozish
+func (wp *WorkerPool) finishAttempt(attempt *db.TaskAttempt, outcome string, err error) {
+	if attempt.ID == "" {
+		return
+	}
+
+	finished := time.Now()
+	duration := finished.Sub(attempt.StartedAt).Milliseconds()
+	attempt.FinishedAt = &finished
+	attempt.DurationMs = &duration
+	attempt.Outcome = outcome
+	if err != nil {
+		msg := err.Error()
+		attempt.Error = &msg
+	}
+
+	if err := wp.db.TaskAttempt().FinishAttempt(context.Background(), *attempt); err != nil {
+		wp.logger.Error("Urinish natijasini yozishda xato", "task_id", attempt.TaskID, "error", err)
+	}
+}
+
+// recordError - urinish xatosini taskning xatolar tarixiga yozish
+func (wp *WorkerPool) recordError(task *db.Task, attempt int, err error) {
+	msg := err.Error()
+	task.LastError = &msg
+
+	if err := wp.db.Task().RecordTaskError(context.Background(), task.ID, attempt, msg); err != nil {
+		wp.logger.Error("Xatoni tarixga yozishda xato", "task_id", task.ID, "error", err)
+	}
+}
+
+// handleTaskError - xatolikni boshqarish
+func (wp *WorkerPool) handleTaskError(task *db.Task, err error) {
+	wp.logger.Error("Taskda xato yuz berdi",
+		"task_id", task.ID,
+		"error", err.Error(),
+	)
+
+	// Qayta urinishlar sonini yangilash
+	task.Retries++
+
+	// Handler yo'q, xato qayta urinib bo'lmaydigan yoki siyosat "none":
+	// task darhol dead-letterga tushadi
+	if !shouldRetry(task.RetryPolicy, err) {
+		wp.logger.Error("Task qayta urinilmaydi, dead-letterga o'tkazildi",
+			"task_id", task.ID,
+			"type", task.Type,
+		)
+		if err := wp.updateTaskStatus(task, "failed"); err == nil {
+			wp.finished(task)
+		}
+		return
+	}
+
+	// Qayta urinishlar chegarasini tekshirish
+	if task.Retries >= task.MaxRetries {
+		wp.logger.Error("Maksimal qayta urinishlar soniga yetildi, task dead-letterga o'tkazildi",
+			"task_id", task.ID,
+			"max_retries", task.MaxRetries,
+		)
+		if err := wp.updateTaskStatus(task, "failed"); err == nil {
+			wp.finished(task)
+		}
+		return
+	}
+
+	// Taskning siyosati bo'yicha kechikishni hisoblash
+	nextRetry := nextRetryAt(*task)
+	task.NextRetryAt = &nextRetry
+
+	// Taskni navbatga qaytarish: next_retry_at kelgach scheduler uni dispatch qiladi
+	if err := wp.updateTaskStatus(task, "pending"); err != nil {
+		return
+	}
+	wp.Enqueue(task)
+}
+
+// OnFinished - task yakuniy holatga o'tganda chaqiriladigan funksiyani qo'shish.
+// Start dan oldin chaqirilishi kerak.
+func (wp *WorkerPool) OnFinished(fn func(task *db.Task)) {
+	wp.finishHooks = append(wp.finishHooks, fn)
+}
+
+// finished - task yakuniy holatga (completed, failed, cancelled) o'tgandan keyin chaqiriladi.
+// Muvaffaqiyatli tugagan taskka bog'liq tasklar endi bajarilishi mumkin; aks holda
+// ular hech qachon bajarilmaydi va "skipped" qilinadi.
+func (wp *WorkerPool) finished(task *db.Task) {
+	for _, fn := range wp.finishHooks {
+		fn(task)
+	}
+
+	if task.Status == "completed" {
+		ready, err := wp.db.Workflow().ReadyDependants(context.Background(), task.ID)
+		if err != nil {
+			wp.logger.Error("Bog'liq tasklarni olishda xato", "task_id", task.ID, "error", err)
+		}
+		for i := range ready {
+			wp.dispatch(&ready[i])
+		}
+		wp.Notify()
+		return
+	}
+
+	skipped, err := wp.db.Workflow().SkipDependants(context.Background(), task.ID)
+	if err != nil {
+		wp.logger.Error("Bog'liq tasklarni o'tkazib yuborishda xato", "task_id", task.ID, "error", err)
+		return
+	}
+	for i := range skipped {
+		wp.logger.Warn("Ota task muvaffaqiyatsiz, task o'tkazib yuborildi",
+			"task_id", skipped[i].ID,
+			"parent_id", task.ID,
+		)
+		wp.publishEvent(&skipped[i])
+		for _, fn := range wp.finishHooks {
+			fn(&skipped[i])
+		}
+	}
+}
+
+// updateTaskStatus - worker olgan task statusini yangilash. Task shu orada reaper
+// tomonidan qaytarilgan (va boshqa workerga berilgan), bekor qilingan yoki o'chirilgan
+// bo'lsa hech narsa yozilmaydi va storage.ErrTaskNotOwned qaytadi.
+func (wp *WorkerPool) updateTaskStatus(task *db.Task, status string) error {
+	var owner string
+	if task.ClaimedBy != nil {
+		owner = *task.ClaimedBy
+	}
+
+	task.Status = status
+	task.UpdatedAt = time.Now()
+	if status != "processing" {
+		// Task workerdan bo'shatiladi
+		task.ClaimedBy = nil
+		task.LeaseExpiresAt = nil
+	}
+
+	if err := wp.db.Task().UpdateClaimedTask(context.Background(), *task, owner); err != nil {
+		if errors.Is(err, storage.ErrTaskNotOwned) {
+			wp.logger.Warn("Task endi bu workerga tegishli emas, natija yozilmadi",
+				"task_id", task.ID,
+				"worker", owner,
+				"status", status,
+			)
+			return err
+		}
+		wp.logger.Error("Statusni yangilashda xato",
+			"task_id", task.ID,
+			"error", err.Error(),
+		)
+		return err
+	}
+	wp.publishEvent(task)
+	return nil
+}
+
+// saveTaskResult - task natijasini saqlash
+func (wp *WorkerPool) saveTaskResult(task *db.Task) error {
+	result := db.TaskResult{
+		ID:          uuid.NewString(),
+		TaskID:      task.ID,
+		CompletedAt: time.Now(),
+	}
+
+	return wp.db.TaskResult().CreateResult(context.Background(), result)
+}
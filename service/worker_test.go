@@ -0,0 +1,174 @@
+package service
+
+import (
+	"asynchronous/config"
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// drainTasks - bitta taskning bazadagi holati
+type drainTasks struct {
+	storage.ITaskStorage
+
+	mu       sync.Mutex
+	task     db.Task
+	errors   int // RecordTaskError chaqiruvlari
+	statuses []string
+}
+
+func (f *drainTasks) ExtendLease(ctx context.Context, taskID, workerID string, lease time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (f *drainTasks) ReleaseTask(ctx context.Context, taskID, workerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.task.Status != "processing" || f.task.ClaimedBy == nil || *f.task.ClaimedBy != workerID {
+		return storage.ErrTaskNotOwned
+	}
+	f.task.Status = "pending"
+	f.task.ClaimedBy = nil
+	return nil
+}
+
+func (f *drainTasks) RecordTaskError(ctx context.Context, taskID string, attempt int, msg string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors++
+	return nil
+}
+
+func (f *drainTasks) UpdateClaimedTask(ctx context.Context, task db.Task, workerID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses = append(f.statuses, task.Status)
+	f.task = task
+	return nil
+}
+
+// drainAttempts - urinishlar natijasi
+type drainAttempts struct {
+	storage.ITaskAttemptStorage
+
+	mu       sync.Mutex
+	outcomes []string
+}
+
+func (f *drainAttempts) StartAttempt(ctx context.Context, attempt db.TaskAttempt) (db.TaskAttempt, error) {
+	attempt.ID = "attempt-1"
+	return attempt, nil
+}
+
+func (f *drainAttempts) FinishAttempt(ctx context.Context, attempt db.TaskAttempt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.outcomes = append(f.outcomes, attempt.Outcome)
+	return nil
+}
+
+type nopEvents struct{ storage.ITaskEventStorage }
+
+func (nopEvents) Publish(ctx context.Context, event db.TaskEvent) error { return nil }
+
+type nopWebhooks struct{ storage.IWebhookStorage }
+
+func (nopWebhooks) EnqueueDeliveries(ctx context.Context, userIDs []string, taskID, event string, payload []byte) (int64, error) {
+	return 0, nil
+}
+
+// pushQueue - navbatga yuborilgan tasklar
+type pushQueue struct {
+	mu     sync.Mutex
+	pushed []string
+}
+
+func (q *pushQueue) Push(ctx context.Context, task *db.Task) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pushed = append(q.pushed, task.ID)
+	return nil
+}
+
+func (q *pushQueue) Pop(ctx context.Context, opts storage.ClaimOptions) (*Delivery, error) {
+	return nil, storage.ErrNoTask
+}
+
+func (q *pushQueue) Ack(ctx context.Context, d *Delivery) error { return nil }
+
+func (q *pushQueue) Leave(ctx context.Context, consumer string) error { return nil }
+
+func newDrainPool(tasks *drainTasks, attempts *drainAttempts, queue *pushQueue, handlers *handlerRegistry) *WorkerPool {
+	pdb := &fakeStorage{task: tasks, attempt: attempts, event: nopEvents{}, webhook: nopWebhooks{}}
+	return NewWorkerPool(pdb, discardLogger(), config.WorkerConfig{Lease: time.Minute}, queue, handlers)
+}
+
+func TestStopReleasesTaskWhoseHandlerReturnsOnCancel(t *testing.T) {
+	tasks := &drainTasks{}
+	attempts := &drainAttempts{}
+	queue := &pushQueue{}
+	handlers := newHandlerRegistry()
+
+	started := make(chan struct{})
+	handlers.register("drain", TaskHandlerFunc(func(ctx context.Context, task *db.Task, payload map[string]interface{}) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	wp := newDrainPool(tasks, attempts, queue, handlers)
+
+	worker := wp.workerName(1)
+	task := db.Task{ID: "task-1", Type: "drain", Status: "processing", ClaimedBy: &worker, Retries: 1, MaxRetries: 3}
+	tasks.task = task
+
+	wp.wg.Add(1)
+	go func() {
+		defer wp.wg.Done()
+		wp.processTask(1, &task)
+	}()
+	<-started
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // Kutish muddati allaqachon tugagan
+	if err := wp.Stop(ctx); err == nil {
+		t.Fatalf("Stop = nil, want drain timeout error")
+	}
+	wp.wg.Wait()
+
+	if tasks.task.Status != "pending" || tasks.task.Retries != 1 {
+		t.Fatalf("task = status %s, retries %d; want pending, 1", tasks.task.Status, tasks.task.Retries)
+	}
+	if tasks.errors != 0 || len(tasks.statuses) != 0 {
+		t.Fatalf("drained task was handled as a failure: %d errors recorded, status writes %v", tasks.errors, tasks.statuses)
+	}
+	if len(attempts.outcomes) != 1 || attempts.outcomes[0] != db.AttemptReleased {
+		t.Fatalf("attempt outcomes = %v, want [%s]", attempts.outcomes, db.AttemptReleased)
+	}
+	if len(queue.pushed) != 1 {
+		t.Fatalf("pushed = %v, want the released task", queue.pushed)
+	}
+}
+
+func TestReleaseTaskSkipsTaskOwnedByAnotherWorker(t *testing.T) {
+	queue := &pushQueue{}
+	other := "other-instance-1"
+	tasks := &drainTasks{task: db.Task{ID: "task-1", Status: "processing", ClaimedBy: &other}}
+	wp := newDrainPool(tasks, &drainAttempts{}, queue, newHandlerRegistry())
+
+	// Reaper taskni qaytarib, boshqa worker olgan: eski worker uni pending ga qaytarmaydi
+	task := db.Task{ID: "task-1", Status: "processing"}
+	if wp.releaseTask(&task, wp.workerName(1)) {
+		t.Fatalf("releaseTask = true for a task claimed by another worker")
+	}
+	if tasks.task.Status != "processing" || len(queue.pushed) != 0 {
+		t.Fatalf("task = %s, pushed %v; want untouched", tasks.task.Status, queue.pushed)
+	}
+
+	if !wp.releaseTask(&task, other) || tasks.task.Status != "pending" {
+		t.Fatalf("owner could not release its task")
+	}
+}
@@ -0,0 +1,208 @@
+// service/workflow_service.go
+package service
+
+import (
+	"asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWorkflow - workflow ta'rifi noto'g'ri (takroriy kalit, noma'lum bog'liqlik, sikl)
+var ErrInvalidWorkflow = errors.New("workflow ma'lumotlari noto'g'ri")
+
+// WorkflowTask - workflowdagi bitta task. Key faqat so'rov ichida bog'liqliklarni
+// ko'rsatish uchun ishlatiladi; DependsOn boshqa tasklarning kalitlari.
+type WorkflowTask struct {
+	Key       string
+	Task      db.Task
+	DependsOn []string
+}
+
+// CreateWorkflow - tasklar DAG ini bitta tranzaksiyada yaratish. Ota tasklari
+// bo'lmagan tasklar darhol navbatga tushadi, qolganlari ota tasklari tugashini kutadi.
+func (s *TaskService) CreateWorkflow(ctx context.Context, creatorID, name string, items []WorkflowTask) (*db.WorkflowStatus, error) {
+	if name == "" {
+		return nil, fmt.Errorf("%w: name bo'sh bo'lishi mumkin emas", ErrInvalidWorkflow)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("%w: workflowda kamida bitta task bo'lishi kerak", ErrInvalidWorkflow)
+	}
+
+	now := time.Now()
+	wf := db.Workflow{
+		ID:        uuid.NewString(),
+		CreatorID: creatorID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	ids := make(map[string]string, len(items)) // kalit -> task ID
+	tasks := make([]db.Task, 0, len(items))
+	for _, item := range items {
+		if item.Key == "" {
+			return nil, fmt.Errorf("%w: har bir taskda key bo'lishi kerak", ErrInvalidWorkflow)
+		}
+		if _, dup := ids[item.Key]; dup {
+			return nil, fmt.Errorf("%w: takroriy key: %s", ErrInvalidWorkflow, item.Key)
+		}
+
+		task := item.Task
+		if err := validateTask(&task); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidWorkflow, item.Key, err)
+		}
+		task.ID = uuid.NewString()
+		task.CreatorID = creatorID
+		task.WorkflowID = &wf.ID
+		task.Status = "pending"
+		task.CreatedAt = now
+		task.UpdatedAt = now
+
+		ids[item.Key] = task.ID
+		tasks = append(tasks, task)
+	}
+
+	var deps []db.TaskDependency
+	for _, item := range items {
+		seen := make(map[string]bool, len(item.DependsOn))
+		for _, parent := range item.DependsOn {
+			if seen[parent] {
+				return nil, fmt.Errorf("%w: %s bog'liqligi takrorlangan: %s", ErrInvalidWorkflow, item.Key, parent)
+			}
+			seen[parent] = true
+
+			parentID, ok := ids[parent]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s noma'lum taskka bog'liq: %s", ErrInvalidWorkflow, item.Key, parent)
+			}
+			if parent == item.Key {
+				return nil, fmt.Errorf("%w: %s o'ziga bog'liq bo'lishi mumkin emas", ErrInvalidWorkflow, item.Key)
+			}
+			deps = append(deps, db.TaskDependency{TaskID: ids[item.Key], DependsOn: parentID})
+		}
+	}
+	if err := checkAcyclic(items); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Workflow().CreateWorkflow(ctx, wf, tasks, deps); err != nil {
+		s.logger.Error("Workflowni saqlashda xato", "error", err)
+		return nil, fmt.Errorf("workflowni saqlashda xato: %w", err)
+	}
+
+	// Bog'liq tasklarni ClaimTask o'zi ota tasklar tugaguncha o'tkazib yuboradi
+	for i := range tasks {
+		s.workerPool.Enqueue(&tasks[i])
+	}
+	s.logger.Info("Workflow yaratildi", "workflow_id", wf.ID, "tasks", len(tasks), "dependencies", len(deps))
+
+	return s.GetWorkflow(ctx, wf.ID)
+}
+
+// checkAcyclic - bog'liqliklarda sikl yo'qligini tekshirish (Kahn algoritmi)
+func checkAcyclic(items []WorkflowTask) error {
+	indegree := make(map[string]int, len(items))
+	children := make(map[string][]string, len(items))
+	for _, item := range items {
+		if _, ok := indegree[item.Key]; !ok {
+			indegree[item.Key] = 0
+		}
+		for _, parent := range item.DependsOn {
+			indegree[item.Key]++
+			children[parent] = append(children[parent], item.Key)
+		}
+	}
+
+	var ready []string
+	for key, n := range indegree {
+		if n == 0 {
+			ready = append(ready, key)
+		}
+	}
+
+	visited := 0
+	for len(ready) > 0 {
+		key := ready[len(ready)-1]
+		ready = ready[:len(ready)-1]
+		visited++
+
+		for _, child := range children[key] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	if visited != len(items) {
+		return fmt.Errorf("%w: bog'liqliklarda sikl bor", ErrInvalidWorkflow)
+	}
+	return nil
+}
+
+// GetWorkflow - workflow, uning tasklari va umumiy holati
+func (s *TaskService) GetWorkflow(ctx context.Context, id string) (*db.WorkflowStatus, error) {
+	wf, err := s.storage.Workflow().GetWorkflow(ctx, id)
+	if err != nil {
+		if !errors.Is(err, storage.ErrWorkflowNotFound) {
+			s.logger.Error("Workflowni olishda xato", "workflow_id", id, "error", err)
+		}
+		return nil, err
+	}
+
+	tasks, err := s.storage.Workflow().ListWorkflowTasks(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	deps, err := s.storage.Workflow().ListDependencies(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &db.WorkflowStatus{
+		Workflow:     wf,
+		Counts:       make(map[string]int),
+		Tasks:        tasks,
+		Dependencies: deps,
+	}
+	for _, task := range tasks {
+		status.Counts[task.Status]++
+	}
+	status.Status = workflowStatus(status.Counts, len(tasks))
+
+	return status, nil
+}
+
+// workflowStatus - tasklar holatidan workflowning umumiy holati:
+// hali tugamagan task bo'lsa "running", hammasi completed bo'lsa "completed", aks holda "failed"
+func workflowStatus(counts map[string]int, total int) string {
+	switch {
+	case counts["pending"]+counts["processing"] > 0:
+		return "running"
+	case counts["completed"] == total:
+		return "completed"
+	default:
+		return "failed"
+	}
+}
+
+// unskipDependants - task qayta navbatga qo'yilganda uning sababli o'tkazib yuborilgan
+// tasklarni yana kutish holatiga qaytarish
+func (s *TaskService) unskipDependants(ctx context.Context, taskID string) {
+	tasks, err := s.storage.Workflow().UnskipDependants(ctx, taskID)
+	if err != nil {
+		s.logger.Error("Bog'liq tasklarni qaytarishda xato", "task_id", taskID, "error", err)
+		return
+	}
+	for i := range tasks {
+		s.workerPool.publishEvent(&tasks[i])
+	}
+	if len(tasks) > 0 {
+		s.logger.Info("O'tkazib yuborilgan bog'liq tasklar qaytarildi", "task_id", taskID, "count", len(tasks))
+	}
+}
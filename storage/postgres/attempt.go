@@ -0,0 +1,119 @@
+// storage/postgres/task_attempt_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type TaskAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewTaskAttemptRepository(db *sql.DB) storage.ITaskAttemptStorage {
+	return &TaskAttemptRepository{db: db}
+}
+
+// StartAttempt - yangi urinishni "running" holatida yozish. Tartib raqami task bo'yicha
+// oldingi urinishlardan keyingisi bo'ladi (task dead-letterdan qaytarilsa ham davom etadi).
+func (r *TaskAttemptRepository) StartAttempt(ctx context.Context, attempt models.TaskAttempt) (models.TaskAttempt, error) {
+	attempt.ID = uuid.New().String()
+	attempt.Outcome = models.AttemptRunning
+
+	query := `
+		INSERT INTO task_attempts (id, task_id, attempt, worker_id, started_at, outcome)
+		SELECT $1, $2, COALESCE(MAX(attempt), 0) + 1, $3, $4, $5
+		FROM task_attempts
+		WHERE task_id = $2
+		RETURNING attempt`
+
+	err := r.db.QueryRowContext(ctx, query,
+		attempt.ID,
+		attempt.TaskID,
+		attempt.WorkerID,
+		attempt.StartedAt,
+		attempt.Outcome,
+	).Scan(&attempt.Attempt)
+	if err != nil {
+		return models.TaskAttempt{}, fmt.Errorf("urinishni yozishda xato: %w", err)
+	}
+
+	return attempt, nil
+}
+
+func (r *TaskAttemptRepository) FinishAttempt(ctx context.Context, attempt models.TaskAttempt) error {
+	query := `
+		UPDATE task_attempts SET
+			finished_at = $2,
+			duration_ms = $3,
+			outcome = $4,
+			error = $5
+		WHERE id = $1`
+
+	_, err := r.db.ExecContext(ctx, query,
+		attempt.ID,
+		attempt.FinishedAt,
+		attempt.DurationMs,
+		attempt.Outcome,
+		attempt.Error,
+	)
+	return err
+}
+
+// AbandonAttempts - qulab tushgan worker yakunlay olmagan urinishlarni "lease_lost" deb yopish
+func (r *TaskAttemptRepository) AbandonAttempts(ctx context.Context, taskIDs []string) error {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		UPDATE task_attempts SET
+			finished_at = NOW(),
+			duration_ms = (EXTRACT(EPOCH FROM (NOW() - started_at)) * 1000)::BIGINT,
+			outcome = $2
+		WHERE task_id = ANY($1::UUID[]) AND outcome = 'running'`
+
+	_, err := r.db.ExecContext(ctx, query, pq.Array(taskIDs), models.AttemptLeaseLost)
+	return err
+}
+
+func (r *TaskAttemptRepository) ListAttempts(ctx context.Context, taskID string) ([]models.TaskAttempt, error) {
+	query := `
+		SELECT id, task_id, attempt, worker_id, started_at, finished_at, duration_ms, outcome, error
+		FROM task_attempts
+		WHERE task_id = $1
+		ORDER BY attempt`
+
+	rows, err := r.db.QueryContext(ctx, query, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("urinishlar tarixini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []models.TaskAttempt
+	for rows.Next() {
+		var a models.TaskAttempt
+		if err := rows.Scan(
+			&a.ID,
+			&a.TaskID,
+			&a.Attempt,
+			&a.WorkerID,
+			&a.StartedAt,
+			&a.FinishedAt,
+			&a.DurationMs,
+			&a.Outcome,
+			&a.Error,
+		); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
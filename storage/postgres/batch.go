@@ -0,0 +1,152 @@
+// storage/postgres/batch_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const batchColumns = `id, creator_id, name, total, on_complete, completed_at, created_at, updated_at`
+
+func scanBatch(row rowScanner) (models.Batch, error) {
+	var b models.Batch
+	err := row.Scan(
+		&b.ID,
+		&b.CreatorID,
+		&b.Name,
+		&b.Total,
+		&b.OnComplete,
+		&b.CompletedAt,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	)
+	return b, err
+}
+
+type BatchRepository struct {
+	db *sql.DB
+}
+
+func NewBatchRepository(db *sql.DB) storage.IBatchStorage {
+	return &BatchRepository{db: db}
+}
+
+func (r *BatchRepository) CreateBatch(ctx context.Context, batch models.Batch, tasks []models.Task) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO batches (id, creator_id, name, total, on_complete, hook_secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8)`,
+		batch.ID,
+		batch.CreatorID,
+		batch.Name,
+		batch.Total,
+		batch.OnComplete,
+		batch.HookSecret,
+		batch.CreatedAt,
+		batch.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("batchni saqlashda xato: %w", err)
+	}
+
+	for _, task := range tasks {
+		if err := insertTask(ctx, tx, task); err != nil {
+			return fmt.Errorf("batch taskini saqlashda xato: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *BatchRepository) GetBatch(ctx context.Context, id string) (models.Batch, error) {
+	query := `SELECT ` + batchColumns + ` FROM batches WHERE id = $1`
+
+	b, err := scanBatch(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Batch{}, storage.ErrBatchNotFound
+	}
+	if err != nil {
+		return models.Batch{}, fmt.Errorf("batchni olishda xato: %w", err)
+	}
+	return b, nil
+}
+
+func (r *BatchRepository) CountByStatus(ctx context.Context, batchID string) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, COUNT(*)
+		FROM tasks
+		WHERE batch_id = $1 AND deleted_at IS NULL
+		GROUP BY status`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("batch statistikasini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+
+	return counts, rows.Err()
+}
+
+func (r *BatchRepository) MarkCompleted(ctx context.Context, batchID string, completedAt time.Time, hook *models.WebhookDelivery) (models.Batch, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Batch{}, false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE batches SET
+			completed_at = $2,
+			updated_at = $2
+		WHERE id = $1
+			AND completed_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM tasks
+				WHERE batch_id = $1
+					AND status IN ('pending', 'processing')
+					AND deleted_at IS NULL
+			)
+		RETURNING ` + batchColumns
+
+	b, err := scanBatch(tx.QueryRowContext(ctx, query, batchID, completedAt))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Batch{}, false, nil
+	}
+	if err != nil {
+		return models.Batch{}, false, fmt.Errorf("batchni yakunlashda xato: %w", err)
+	}
+
+	// Hook yuborilishi batch yakunlangani bilan birga saqlanadi: biri yozilib
+	// ikkinchisi yo'qolmaydi
+	if hook != nil {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (batch_id, event, payload)
+			VALUES ($1, $2, $3)`,
+			batchID, hook.Event, []byte(hook.Payload),
+		); err != nil {
+			return models.Batch{}, false, fmt.Errorf("batch hooki yuborilishini yaratishda xato: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Batch{}, false, fmt.Errorf("batchni yakunlashda xato: %w", err)
+	}
+	return b, true, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import "time"
+
+// maxPriority - eng yuqori priority; aging taskni undan yuqori ko'tarmaydi.
+const maxPriority = 5
+
+// claimCandidate - ClaimTask uchun (navbat, priority) juftligidan olingan eng uzoq kutgan task.
+type claimCandidate struct {
+	ID       string
+	QueuePos int // navbatning opts.Queues dagi o'rni (1 dan)
+	Priority int
+	ReadyAt  time.Time
+}
+
+// agedPriority - aging hisobga olingan priority: task har aging davri kutganda bittaga
+// ko'tariladi, lekin maxPriority dan oshmaydi. aging <= 0 bo'lsa o'zgarmaydi.
+func agedPriority(priority int, waited, aging time.Duration) int {
+	if aging <= 0 || waited <= 0 {
+		return priority
+	}
+	// Juda uzoq kutishda priority+steps to'lib ketmasligi uchun avval steps cheklanadi
+	steps := min(waited/aging, maxPriority)
+	return min(maxPriority, priority+int(steps))
+}
+
+// pickClaim - nomzodlardan band qilinadiganini tanlaydi: avval navbat tartibi, keyin
+// (aging hisobga olingan) priority, keyin eng uzoq kutgan task. Aging yoqilgan bo'lsa
+// past priorityli task kutgan sari ko'tariladi va maxPriority ga yetgach yangi kelgan
+// yuqori priorityli tasklardan oldin turadi (chunki u eskiroq).
+func pickClaim(candidates []claimCandidate, aging time.Duration, now time.Time) (claimCandidate, bool) {
+	if len(candidates) == 0 {
+		return claimCandidate{}, false
+	}
+
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if claimsBefore(c, best, aging, now) {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// claimsBefore - a nomzod b dan oldin band qilinishi kerakmi.
+func claimsBefore(a, b claimCandidate, aging time.Duration, now time.Time) bool {
+	if a.QueuePos != b.QueuePos {
+		return a.QueuePos < b.QueuePos
+	}
+	pa := agedPriority(a.Priority, now.Sub(a.ReadyAt), aging)
+	pb := agedPriority(b.Priority, now.Sub(b.ReadyAt), aging)
+	if pa != pb {
+		return pa > pb
+	}
+	return a.ReadyAt.Before(b.ReadyAt)
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"testing"
+	"time"
+)
+
+// claim_test.go dagi testlar bazasiz ishlaydi; ClaimTask ning o'zi task_test.go da
+// (TEST_PDB_URL bilan) tekshiriladi.
+
+func TestAgedPriority(t *testing.T) {
+	for name, tc := range map[string]struct {
+		priority int
+		waited   time.Duration
+		aging    time.Duration
+		want     int
+	}{
+		"aging disabled":        {1, time.Hour, 0, 1},
+		"not waited yet":        {2, 0, time.Minute, 2},
+		"ready in the future":   {2, -time.Hour, time.Minute, 2},
+		"less than one period":  {2, 59 * time.Second, time.Minute, 2},
+		"one period":            {2, time.Minute, time.Minute, 3},
+		"several periods":       {1, 150 * time.Second, time.Minute, 3},
+		"capped at max":         {1, time.Hour, time.Minute, maxPriority},
+		"max priority stays":    {maxPriority, time.Hour, time.Minute, maxPriority},
+		"huge wait no overflow": {1, 1<<63 - 1, time.Nanosecond, maxPriority},
+	} {
+		if got := agedPriority(tc.priority, tc.waited, tc.aging); got != tc.want {
+			t.Errorf("%s: agedPriority(%d, %v, %v) = %d, want %d",
+				name, tc.priority, tc.waited, tc.aging, got, tc.want)
+		}
+	}
+}
+
+func TestPickClaim(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	for name, tc := range map[string]struct {
+		candidates []claimCandidate
+		aging      time.Duration
+		want       string
+	}{
+		"higher priority first": {
+			candidates: []claimCandidate{
+				{ID: "low", QueuePos: 1, Priority: 1, ReadyAt: ago(time.Hour)},
+				{ID: "high", QueuePos: 1, Priority: 5, ReadyAt: ago(time.Second)},
+				{ID: "mid", QueuePos: 1, Priority: 3, ReadyAt: ago(time.Minute)},
+			},
+			want: "high",
+		},
+		"queue order before priority": {
+			candidates: []claimCandidate{
+				{ID: "second-queue", QueuePos: 2, Priority: 5, ReadyAt: ago(time.Hour)},
+				{ID: "first-queue", QueuePos: 1, Priority: 1, ReadyAt: ago(time.Second)},
+			},
+			aging: time.Minute,
+			want:  "first-queue",
+		},
+		"aging promotes old low priority": {
+			candidates: []claimCandidate{
+				{ID: "old", QueuePos: 1, Priority: 1, ReadyAt: ago(10 * time.Minute)},
+				{ID: "new", QueuePos: 1, Priority: 4, ReadyAt: ago(time.Second)},
+			},
+			aging: time.Minute,
+			want:  "old",
+		},
+		"aging not yet enough": {
+			candidates: []claimCandidate{
+				{ID: "old", QueuePos: 1, Priority: 1, ReadyAt: ago(2 * time.Minute)},
+				{ID: "new", QueuePos: 1, Priority: 4, ReadyAt: ago(time.Second)},
+			},
+			aging: time.Minute,
+			want:  "new",
+		},
+		"without aging old low priority waits": {
+			candidates: []claimCandidate{
+				{ID: "old", QueuePos: 1, Priority: 1, ReadyAt: ago(24 * time.Hour)},
+				{ID: "new", QueuePos: 1, Priority: 2, ReadyAt: ago(time.Second)},
+			},
+			want: "new",
+		},
+		"equal aged priority oldest first": {
+			candidates: []claimCandidate{
+				{ID: "fresh-max", QueuePos: 1, Priority: 5, ReadyAt: ago(time.Second)},
+				{ID: "aged-to-max", QueuePos: 1, Priority: 2, ReadyAt: ago(time.Hour)},
+			},
+			aging: time.Minute,
+			want:  "aged-to-max",
+		},
+	} {
+		got, ok := pickClaim(tc.candidates, tc.aging, now)
+		if !ok || got.ID != tc.want {
+			t.Errorf("%s: pickClaim = %q (ok=%v), want %q", name, got.ID, ok, tc.want)
+		}
+	}
+
+	if _, ok := pickClaim(nil, time.Minute, now); ok {
+		t.Error("pickClaim(nil) ok = true, want false")
+	}
+}
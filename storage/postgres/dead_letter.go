@@ -0,0 +1,162 @@
+// storage/postgres/dead_letter_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const deadLetterColumns = `
+			id, creator_id, user_id, title, type, priority, payload,
+			retries, max_retries, COALESCE(last_error, ''), error_history, dead_lettered_at`
+
+func scanDeadLetter(row rowScanner) (models.DeadLetter, error) {
+	var dl models.DeadLetter
+	var payload, history []byte
+
+	if err := row.Scan(
+		&dl.TaskID,
+		&dl.CreatorID,
+		&dl.UserID,
+		&dl.Title,
+		&dl.Type,
+		&dl.Priority,
+		&payload,
+		&dl.Retries,
+		&dl.MaxRetries,
+		&dl.LastError,
+		&history,
+		&dl.DeadLetteredAt,
+	); err != nil {
+		return models.DeadLetter{}, err
+	}
+
+	json.Unmarshal(payload, &dl.Payload)
+	json.Unmarshal(history, &dl.ErrorHistory)
+	return dl, nil
+}
+
+// deadLetterWhere - filter bo'yicha WHERE sharti va argumentlar (argumentlar $1 dan boshlanadi)
+func deadLetterWhere(filter storage.DeadLetterFilter) (string, []interface{}) {
+	where := ""
+	var args []interface{}
+
+	if len(filter.IDs) > 0 {
+		args = append(args, pq.Array(filter.IDs))
+		where += fmt.Sprintf(" AND id = ANY($%d::UUID[])", len(args))
+	}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		where += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+	return where, args
+}
+
+type DeadLetterRepository struct {
+	db *sql.DB
+}
+
+func NewDeadLetterRepository(db *sql.DB) storage.IDeadLetterStorage {
+	return &DeadLetterRepository{db: db}
+}
+
+func (r *DeadLetterRepository) ListDeadLetters(ctx context.Context, filter storage.DeadLetterFilter, limit, offset int) ([]models.DeadLetter, error) {
+	where, args := deadLetterWhere(filter)
+
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letter_tasks
+		WHERE TRUE` + where +
+		fmt.Sprintf(" ORDER BY dead_lettered_at DESC LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter tasklarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.DeadLetter
+	for rows.Next() {
+		dl, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, dl)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *DeadLetterRepository) GetDeadLetter(ctx context.Context, taskID string) (models.DeadLetter, error) {
+	query := `
+		SELECT ` + deadLetterColumns + `
+		FROM dead_letter_tasks
+		WHERE id = $1`
+
+	dl, err := scanDeadLetter(r.db.QueryRowContext(ctx, query, taskID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.DeadLetter{}, storage.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return models.DeadLetter{}, fmt.Errorf("dead-letter taskni olishda xato: %w", err)
+	}
+	return dl, nil
+}
+
+// Requeue - tasklar xatolar tarixi saqlangan holda qayta navbatga qo'yiladi
+func (r *DeadLetterRepository) Requeue(ctx context.Context, filter storage.DeadLetterFilter) ([]models.Task, error) {
+	where, args := deadLetterWhere(filter)
+
+	query := `
+		UPDATE tasks SET
+			status = 'pending',
+			retries = 0,
+			next_retry_at = NULL,
+			dead_lettered_at = NULL,
+			updated_at = NOW()
+		WHERE status = 'failed'
+			AND dead_lettered_at IS NOT NULL
+			AND deleted_at IS NULL` + where + `
+		RETURNING ` + taskColumns
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("dead-letter tasklarni qayta navbatga qo'yishda xato: %w", uniqueKeyConflict(err))
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, uniqueKeyConflict(rows.Err())
+}
+
+func (r *DeadLetterRepository) Purge(ctx context.Context, filter storage.DeadLetterFilter) (int64, error) {
+	where, args := deadLetterWhere(filter)
+
+	query := `
+		DELETE FROM tasks
+		WHERE status = 'failed'
+			AND dead_lettered_at IS NOT NULL
+			AND deleted_at IS NULL` + where
+
+	res, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("dead-letter tasklarni o'chirishda xato: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,109 @@
+// storage/postgres/event_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// taskEventsChannel - task hodisalari yuboriladigan LISTEN/NOTIFY kanali
+const taskEventsChannel = "task_events"
+
+type TaskEventRepository struct {
+	db *sql.DB
+}
+
+func NewTaskEventRepository(db *sql.DB) storage.ITaskEventStorage {
+	return &TaskEventRepository{db: db}
+}
+
+// Publish - hodisani NOTIFY orqali shu kanalni tinglayotgan barcha ulanishlarga yuborish.
+// NOTIFY xabari 8000 baytdan oshmasligi kerak, shuning uchun hodisada faqat qisqa maydonlar.
+func (r *TaskEventRepository) Publish(ctx context.Context, event models.TaskEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("hodisani kodlashda xato: %w", err)
+	}
+
+	if _, err := r.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, taskEventsChannel, string(payload)); err != nil {
+		return fmt.Errorf("hodisani yuborishda xato: %w", err)
+	}
+	return nil
+}
+
+// ListenTaskEvents - task hodisalarini alohida ulanishda LISTEN qilish. Ulanish uzilsa
+// pq.Listener qayta ulanadi (oraliqdagi hodisalar yo'qoladi). Kanal ctx tugaganda yopiladi.
+func ListenTaskEvents(ctx context.Context, logger *slog.Logger) (<-chan models.TaskEvent, error) {
+	events := make(chan models.TaskEvent, 256)
+	err := listen(ctx, logger, taskEventsChannel, func(n *pq.Notification) bool {
+		if n == nil {
+			// Qayta ulanish bo'ldi
+			return true
+		}
+		var event models.TaskEvent
+		if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+			logger.Error("Task hodisasini o'qishda xato", "error", err)
+			return true
+		}
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}, func() { close(events) })
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// listen - channel ni alohida ulanishda LISTEN qilish va har bir xabarni handle ga berish
+// (qayta ulanishda nil bilan). handle false qaytarsa yoki ctx tugasa tinglash to'xtaydi
+// va done chaqiriladi.
+func listen(ctx context.Context, logger *slog.Logger, channel string, handle func(n *pq.Notification) bool, done func()) error {
+	listener := pq.NewListener(connString(), 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error("LISTEN ulanishida xato", "channel", channel, "error", err)
+		}
+		if ev == pq.ListenerEventReconnected {
+			logger.Warn("LISTEN ulanishi qayta tiklandi, oraliqdagi xabarlar yo'qolgan bo'lishi mumkin", "channel", channel)
+		}
+	})
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return fmt.Errorf("%s kanalini tinglashda xato: %w", channel, err)
+	}
+
+	go func() {
+		defer done()
+		defer listener.Close()
+
+		ping := time.NewTicker(90 * time.Second)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				// Jim ulanish uzilib qolganini aniqlash uchun
+				go listener.Ping()
+			case n := <-listener.Notify:
+				if !handle(n) {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// storage/postgres/limit_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type TaskTypeLimitRepository struct {
+	db *sql.DB
+}
+
+func NewTaskTypeLimitRepository(db *sql.DB) storage.ITaskTypeLimitStorage {
+	return &TaskTypeLimitRepository{db: db}
+}
+
+func (r *TaskTypeLimitRepository) ListLimits(ctx context.Context) ([]models.TaskTypeLimit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT type, max_concurrency, rate_per_second, burst, updated_at
+		FROM task_type_limits
+		ORDER BY type`)
+	if err != nil {
+		return nil, fmt.Errorf("cheklovlarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var limits []models.TaskTypeLimit
+	for rows.Next() {
+		var l models.TaskTypeLimit
+		if err := rows.Scan(&l.Type, &l.MaxConcurrency, &l.RatePerSecond, &l.Burst, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		limits = append(limits, l)
+	}
+	return limits, rows.Err()
+}
+
+func (r *TaskTypeLimitRepository) UpsertLimit(ctx context.Context, limit models.TaskTypeLimit) (models.TaskTypeLimit, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO task_type_limits (type, max_concurrency, rate_per_second, burst, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (type) DO UPDATE SET
+			max_concurrency = EXCLUDED.max_concurrency,
+			rate_per_second = EXCLUDED.rate_per_second,
+			burst = EXCLUDED.burst,
+			updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`,
+		limit.Type, limit.MaxConcurrency, limit.RatePerSecond, limit.Burst,
+	).Scan(&limit.UpdatedAt)
+	if err != nil {
+		return models.TaskTypeLimit{}, fmt.Errorf("cheklovni saqlashda xato: %w", err)
+	}
+	return limit, nil
+}
+
+func (r *TaskTypeLimitRepository) DeleteLimit(ctx context.Context, taskType string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM task_type_limits WHERE type = $1`, taskType)
+	if err != nil {
+		return fmt.Errorf("cheklovni o'chirishda xato: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrTaskTypeLimitNotFound
+	}
+	return nil
+}
+
+func (r *TaskTypeLimitRepository) RunningCounts(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.type, COUNT(*)
+		FROM tasks t
+		JOIN task_type_limits l ON l.type = t.type
+		WHERE t.status = 'processing' AND t.deleted_at IS NULL
+		GROUP BY t.type`)
+	if err != nil {
+		return nil, fmt.Errorf("bajarilayotgan tasklarni sanashda xato: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var taskType string
+		var n int
+		if err := rows.Scan(&taskType, &n); err != nil {
+			return nil, err
+		}
+		counts[taskType] = n
+	}
+	return counts, rows.Err()
+}
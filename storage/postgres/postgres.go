@@ -4,9 +4,10 @@ import (
 	"asynchronous/config"
 	"asynchronous/storage"
 	"database/sql"
+	"errors"
 	"fmt"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type postgresStorage struct {
@@ -19,11 +20,25 @@ func NewPostgresStorage(db *sql.DB) storage.IStorage {
 	}
 }
 
-func ConnectionDb() (*sql.DB, error) {
+// connString - Postgres ulanish satri
+func connString() string {
 	conf := config.Load()
-	conDb := fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
+	return fmt.Sprintf("host=%s port=%s user=%s dbname=%s password=%s sslmode=disable",
 		conf.Postgres.PDB_HOST, conf.Postgres.PDB_PORT, conf.Postgres.PDB_USER, conf.Postgres.PDB_NAME, conf.Postgres.PDB_PASSWORD)
-	db, err := sql.Open("postgres", conDb)
+}
+
+// uniqueKeyConflict - idx_tasks_unique_key buzilishini (23505) storage.ErrUniqueConflict ga aylantirish:
+// task pending ga qaytarilganda xuddi shunday task allaqachon navbatda bo'lishi mumkin
+func uniqueKeyConflict(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_tasks_unique_key" {
+		return fmt.Errorf("%w: %v", storage.ErrUniqueConflict, err)
+	}
+	return err
+}
+
+func ConnectionDb() (*sql.DB, error) {
+	db, err := sql.Open("postgres", connString())
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +65,43 @@ func (p *postgresStorage) User() storage.IUserStorage {
 func (p *postgresStorage) TaskResult() storage.ITaskResultStorage {
 	return NewTaskResultRepository(p.db)
 }
+
+func (p *postgresStorage) RecurringTask() storage.IRecurringTaskStorage {
+	return NewRecurringTaskRepository(p.db)
+}
+
+func (p *postgresStorage) DeadLetter() storage.IDeadLetterStorage {
+	return NewDeadLetterRepository(p.db)
+}
+
+func (p *postgresStorage) TaskAttempt() storage.ITaskAttemptStorage {
+	return NewTaskAttemptRepository(p.db)
+}
+
+func (p *postgresStorage) Workflow() storage.IWorkflowStorage {
+	return NewWorkflowRepository(p.db)
+}
+
+func (p *postgresStorage) Batch() storage.IBatchStorage {
+	return NewBatchRepository(p.db)
+}
+
+func (p *postgresStorage) TaskTypeLimit() storage.ITaskTypeLimitStorage {
+	return NewTaskTypeLimitRepository(p.db)
+}
+
+func (p *postgresStorage) Queue() storage.IQueueStorage {
+	return NewQueueRepository(p.db)
+}
+
+func (p *postgresStorage) TaskEvent() storage.ITaskEventStorage {
+	return NewTaskEventRepository(p.db)
+}
+
+func (p *postgresStorage) Worker() storage.IWorkerStorage {
+	return NewWorkerRepository(p.db)
+}
+
+func (p *postgresStorage) Webhook() storage.IWebhookStorage {
+	return NewWebhookRepository(p.db)
+}
@@ -0,0 +1,87 @@
+// storage/postgres/queue_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type QueueRepository struct {
+	db *sql.DB
+}
+
+func NewQueueRepository(db *sql.DB) storage.IQueueStorage {
+	return &QueueRepository{db: db}
+}
+
+func (r *QueueRepository) ListPaused(ctx context.Context) ([]models.PausedQueue, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT queue, paused_by, paused_at
+		FROM paused_queues
+		ORDER BY queue`)
+	if err != nil {
+		return nil, fmt.Errorf("to'xtatilgan navbatlarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.PausedQueue
+	for rows.Next() {
+		var q models.PausedQueue
+		if err := rows.Scan(&q.Queue, &q.PausedBy, &q.PausedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, q)
+	}
+	return list, rows.Err()
+}
+
+func (r *QueueRepository) Pause(ctx context.Context, queue, pausedBy string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO paused_queues (queue, paused_by, paused_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NOW())
+		ON CONFLICT (queue) DO NOTHING`,
+		queue, pausedBy,
+	)
+	if err != nil {
+		return fmt.Errorf("navbatni to'xtatishda xato: %w", err)
+	}
+	return nil
+}
+
+func (r *QueueRepository) Resume(ctx context.Context, queue string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM paused_queues WHERE queue = $1`, queue)
+	if err != nil {
+		return false, fmt.Errorf("navbatni davom ettirishda xato: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *QueueRepository) Depths(ctx context.Context) ([]models.QueueDepth, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT queue,
+			COUNT(*) FILTER (WHERE status = 'pending'),
+			COUNT(*) FILTER (WHERE `+readyCondition+`),
+			COUNT(*) FILTER (WHERE status = 'processing')
+		FROM tasks
+		WHERE status IN ('pending', 'processing') AND deleted_at IS NULL
+		GROUP BY queue
+		ORDER BY queue`)
+	if err != nil {
+		return nil, fmt.Errorf("navbatlar hajmini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.QueueDepth
+	for rows.Next() {
+		var d models.QueueDepth
+		if err := rows.Scan(&d.Queue, &d.Pending, &d.Ready, &d.Processing); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
@@ -0,0 +1,292 @@
+// storage/postgres/recurring_task_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const recurringTaskColumns = `
+			id, creator_id, user_id, title, type, priority, queue, payload, max_retries, retry_policy, timeout_seconds,
+			cron_expr, timezone, paused, next_run_at, last_run_at,
+			created_at, updated_at, deleted_at`
+
+func scanRecurringTask(row rowScanner) (models.RecurringTask, error) {
+	var rt models.RecurringTask
+	var payload []byte
+
+	if err := row.Scan(
+		&rt.ID,
+		&rt.CreatorID,
+		&rt.UserID,
+		&rt.Title,
+		&rt.Type,
+		&rt.Priority,
+		&rt.Queue,
+		&payload,
+		&rt.MaxRetries,
+		&rt.RetryPolicy,
+		&rt.TimeoutSeconds,
+		&rt.CronExpr,
+		&rt.Timezone,
+		&rt.Paused,
+		&rt.NextRunAt,
+		&rt.LastRunAt,
+		&rt.CreatedAt,
+		&rt.UpdatedAt,
+		&rt.DeletedAt,
+	); err != nil {
+		return models.RecurringTask{}, err
+	}
+
+	json.Unmarshal(payload, &rt.Payload)
+	return rt, nil
+}
+
+type RecurringTaskRepository struct {
+	db *sql.DB
+}
+
+func NewRecurringTaskRepository(db *sql.DB) storage.IRecurringTaskStorage {
+	return &RecurringTaskRepository{db: db}
+}
+
+func (r *RecurringTaskRepository) CreateRecurringTask(ctx context.Context, rt models.RecurringTask) (string, error) {
+	rt.ID = uuid.New().String()
+
+	query := `
+		INSERT INTO recurring_tasks (
+			id, creator_id, user_id, title, type, priority, queue, payload, max_retries, retry_policy, timeout_seconds,
+			cron_expr, timezone, paused, next_run_at, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	_, err := r.db.ExecContext(ctx, query,
+		rt.ID,
+		rt.CreatorID,
+		rt.UserID,
+		rt.Title,
+		rt.Type,
+		rt.Priority,
+		rt.Queue,
+		rt.Payload,
+		rt.MaxRetries,
+		rt.RetryPolicy,
+		rt.TimeoutSeconds,
+		rt.CronExpr,
+		rt.Timezone,
+		rt.Paused,
+		rt.NextRunAt,
+		time.Now(),
+		time.Now(),
+	)
+
+	return rt.ID, err
+}
+
+func (r *RecurringTaskRepository) GetRecurringTask(ctx context.Context, id string) (models.RecurringTask, error) {
+	query := `
+		SELECT ` + recurringTaskColumns + `
+		FROM recurring_tasks
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	rt, err := scanRecurringTask(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.RecurringTask{}, storage.ErrRecurringTaskNotFound
+	}
+	if err != nil {
+		return models.RecurringTask{}, fmt.Errorf("takrorlanuvchi taskni olishda xato: %w", err)
+	}
+	return rt, nil
+}
+
+func (r *RecurringTaskRepository) ListRecurringTasks(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]models.RecurringTask, error) {
+	var where []string
+	args := []interface{}{}
+	argIDx := 1
+
+	baseQuery := `
+		SELECT ` + recurringTaskColumns + `
+		FROM recurring_tasks
+		WHERE deleted_at IS NULL`
+
+	for key, val := range filters {
+		switch key {
+		case "creator_id", "user_id", "type", "paused":
+			where = append(where, fmt.Sprintf("%s = $%d", key, argIDx))
+			args = append(args, val)
+			argIDx++
+		}
+	}
+
+	if len(where) > 0 {
+		baseQuery += " AND " + strings.Join(where, " AND ")
+	}
+
+	baseQuery += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", argIDx, argIDx+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("takrorlanuvchi tasklar ro'yxatini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.RecurringTask
+	for rows.Next() {
+		rt, err := scanRecurringTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, rt)
+	}
+
+	return list, rows.Err()
+}
+
+func (r *RecurringTaskRepository) SetPaused(ctx context.Context, id string, paused bool, nextRunAt time.Time) error {
+	query := `
+		UPDATE recurring_tasks SET
+			paused = $2,
+			next_run_at = $3,
+			updated_at = $4
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, id, paused, nextRunAt, time.Now())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return storage.ErrRecurringTaskNotFound
+	}
+	return nil
+}
+
+// FireDue - vaqti kelgan ta'riflarni FOR UPDATE SKIP LOCKED bilan band qilib, har biri uchun
+// task yaratadi. Ikki instance bir vaqtda chaqirsa ham har bir ta'rifni faqat bittasi oladi;
+// tasks(recurring_task_id, scheduled_at) unikal indexi esa qo'shimcha himoya.
+// next xato qaytargan ta'rif uchun task yaratilmaydi va u to'xtatiladi (paused),
+// qolganlari odatdagidek commit qilinadi.
+func (r *RecurringTaskRepository) FireDue(ctx context.Context, now time.Time, next func(models.RecurringTask) (time.Time, error)) ([]models.Task, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT ` + recurringTaskColumns + `
+		FROM recurring_tasks
+		WHERE paused = false AND deleted_at IS NULL AND next_run_at <= $1
+		ORDER BY next_run_at
+		LIMIT 100
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("vaqti kelgan takrorlanuvchi tasklarni olishda xato: %w", err)
+	}
+
+	var due []models.RecurringTask
+	for rows.Next() {
+		rt, err := scanRecurringTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		due = append(due, rt)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var created []models.Task
+	for _, rt := range due {
+		nextRunAt, err := next(rt)
+		if err != nil {
+			// Buzilgan ta'rif har tickda qayta olinib, boshqalarini to'xtatib qo'ymasin
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE recurring_tasks SET paused = true, updated_at = $2 WHERE id = $1`,
+				rt.ID, now,
+			); err != nil {
+				return nil, fmt.Errorf("takrorlanuvchi taskni to'xtatishda xato: %w", err)
+			}
+			continue
+		}
+
+		recurringID := rt.ID
+		task := models.Task{
+			ID:              uuid.New().String(),
+			CreatorID:       rt.CreatorID,
+			UserID:          rt.UserID,
+			Title:           rt.Title,
+			Type:            rt.Type,
+			Priority:        rt.Priority,
+			Queue:           rt.Queue,
+			Status:          "pending",
+			Payload:         rt.Payload,
+			MaxRetries:      rt.MaxRetries,
+			RetryPolicy:     rt.RetryPolicy,
+			TimeoutSeconds:  rt.TimeoutSeconds,
+			ScheduledAt:     sql.NullTime{Time: rt.NextRunAt, Valid: true},
+			RecurringTaskID: &recurringID,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO tasks (
+				id, creator_id, user_id, title, type, priority, queue, status,
+				payload, max_retries, retry_policy, timeout_seconds, scheduled_at, recurring_task_id, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+			ON CONFLICT (recurring_task_id, scheduled_at) WHERE recurring_task_id IS NOT NULL DO NOTHING`,
+			task.ID,
+			task.CreatorID,
+			task.UserID,
+			task.Title,
+			task.Type,
+			task.Priority,
+			task.Queue,
+			task.Status,
+			task.Payload,
+			task.MaxRetries,
+			task.RetryPolicy,
+			task.TimeoutSeconds,
+			task.ScheduledAt,
+			task.RecurringTaskID,
+			task.CreatedAt,
+			task.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("takrorlanuvchi taskdan task yaratishda xato: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			created = append(created, task)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE recurring_tasks SET
+				next_run_at = $2,
+				last_run_at = $3,
+				updated_at = $3
+			WHERE id = $1`,
+			rt.ID, nextRunAt, now,
+		); err != nil {
+			return nil, fmt.Errorf("next_run_at ni yangilashda xato: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFireDuePausesBrokenDefinition(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+	repo := NewRecurringTaskRepository(e.db)
+	t.Cleanup(func() {
+		e.db.Exec(`DELETE FROM tasks WHERE queue = $1`, e.queue)
+		e.db.Exec(`DELETE FROM recurring_tasks WHERE creator_id = $1`, e.userID)
+	})
+
+	dueAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+	ids := make(map[string]string)
+	for _, title := range []string{"broken", "healthy"} {
+		id, err := repo.CreateRecurringTask(ctx, models.RecurringTask{
+			CreatorID:  e.userID,
+			UserID:     e.userID,
+			Title:      title,
+			Type:       "test",
+			Priority:   3,
+			Queue:      e.queue,
+			Payload:    []byte(`{}`),
+			MaxRetries: 3,
+			CronExpr:   "@hourly",
+			Timezone:   "UTC",
+			NextRunAt:  dueAt,
+		})
+		if err != nil {
+			t.Fatalf("CreateRecurringTask: %v", err)
+		}
+		ids[title] = id
+	}
+
+	nextRunAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	created, err := repo.FireDue(ctx, time.Now(), func(rt models.RecurringTask) (time.Time, error) {
+		if rt.ID == ids["broken"] {
+			return time.Time{}, errors.New("noto'g'ri timezone")
+		}
+		return nextRunAt, nil
+	})
+	if err != nil {
+		t.Fatalf("FireDue = %v, want the healthy definition to be committed", err)
+	}
+
+	var fired []string
+	for _, task := range created {
+		if task.Queue == e.queue {
+			fired = append(fired, task.Title)
+		}
+	}
+	if len(fired) != 1 || fired[0] != "healthy" {
+		t.Fatalf("created = %v, want [healthy]", fired)
+	}
+
+	broken, err := repo.GetRecurringTask(ctx, ids["broken"])
+	if err != nil {
+		t.Fatalf("GetRecurringTask: %v", err)
+	}
+	if !broken.Paused || !broken.NextRunAt.Equal(dueAt) {
+		t.Fatalf("broken = paused %v, next_run_at %v; want paused and unchanged", broken.Paused, broken.NextRunAt)
+	}
+
+	healthy, err := repo.GetRecurringTask(ctx, ids["healthy"])
+	if err != nil {
+		t.Fatalf("GetRecurringTask: %v", err)
+	}
+	if healthy.Paused || !healthy.NextRunAt.Equal(nextRunAt) {
+		t.Fatalf("healthy = paused %v, next_run_at %v; want active, %v", healthy.Paused, healthy.NextRunAt, nextRunAt)
+	}
+}
@@ -13,8 +13,67 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// taskColumns - tasks jadvalidan o'qiladigan ustunlar (scanTask bilan bir xil tartibda)
+const taskColumns = `
+			id, creator_id, user_id, title, type, priority, queue, status,
+			can_user_change_status, payload, retries, max_retries, retry_policy, timeout_seconds,
+			scheduled_at, next_retry_at, recurring_task_id, workflow_id, batch_id, idempotency_key,
+			unique_key, claimed_by, lease_expires_at, last_error, dead_lettered_at,
+			cancel_reason, cancelled_by, cancelled_at,
+			created_at, updated_at, deleted_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTask - bitta qatorni models.Task ga o'qish
+func scanTask(row rowScanner) (models.Task, error) {
+	var task models.Task
+	var payload []byte
+
+	if err := row.Scan(
+		&task.ID,
+		&task.CreatorID,
+		&task.UserID,
+		&task.Title,
+		&task.Type,
+		&task.Priority,
+		&task.Queue,
+		&task.Status,
+		&task.CanUserChangeStatus,
+		&payload,
+		&task.Retries,
+		&task.MaxRetries,
+		&task.RetryPolicy,
+		&task.TimeoutSeconds,
+		&task.ScheduledAt,
+		&task.NextRetryAt,
+		&task.RecurringTaskID,
+		&task.WorkflowID,
+		&task.BatchID,
+		&task.IdempotencyKey,
+		&task.UniqueKey,
+		&task.ClaimedBy,
+		&task.LeaseExpiresAt,
+		&task.LastError,
+		&task.DeadLetteredAt,
+		&task.CancelReason,
+		&task.CancelledBy,
+		&task.CancelledAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.DeletedAt,
+	); err != nil {
+		return models.Task{}, err
+	}
+
+	json.Unmarshal(payload, &task.Payload)
+	return task, nil
+}
+
 type TaskRepository struct {
 	db *sql.DB
 }
@@ -23,79 +82,137 @@ func NewTaskRepository(db *sql.DB) storage.ITaskStorage {
 	return &TaskRepository{db: db}
 }
 
-func (r *TaskRepository) CreateTask(ctx context.Context, task models.Task) (string, error) {
-	task.ID = uuid.New().String()
+// execer - *sql.DB yoki *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
 
-	query := `
+// insertTaskQuery - insertTaskArgs bilan bir xil tartibda
+const insertTaskQuery = `
     INSERT INTO tasks (
-        id, creator_id, user_id, title, priority, status, 
-        can_user_change_status, payload, retries, max_retries, 
-        scheduled_at, created_at, updated_at
-    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+        id, creator_id, user_id, title, type, priority, queue, status,
+        can_user_change_status, payload, retries, max_retries, retry_policy, timeout_seconds,
+        scheduled_at, recurring_task_id, workflow_id, batch_id, idempotency_key, unique_key,
+        created_at, updated_at
+    ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)`
 
-	_, err := r.db.ExecContext(ctx, query,
+func insertTaskArgs(task models.Task) []interface{} {
+	return []interface{}{
 		task.ID,
 		task.CreatorID,
 		task.UserID,
 		task.Title,
+		task.Type,
 		task.Priority,
+		task.Queue,
 		task.Status,
 		task.CanUserChangeStatus,
 		task.Payload, // To'g'ridan-to'g'ri []byte
 		task.Retries,
 		task.MaxRetries,
+		task.RetryPolicy,
+		task.TimeoutSeconds,
 		task.ScheduledAt,
+		task.RecurringTaskID,
+		task.WorkflowID,
+		task.BatchID,
+		task.IdempotencyKey,
+		task.UniqueKey,
 		task.CreatedAt,
 		task.UpdatedAt,
-	)
+	}
+}
 
+// insertTask - ID si to'ldirilgan taskni yozish (tranzaksiya ichida ham ishlatiladi)
+func insertTask(ctx context.Context, db execer, task models.Task) error {
+	_, err := db.ExecContext(ctx, insertTaskQuery, insertTaskArgs(task)...)
+	return err
+}
+
+func (r *TaskRepository) CreateTask(ctx context.Context, task models.Task) (string, error) {
+	task.ID = uuid.New().String()
+
+	err := insertTask(ctx, r.db, task)
 	return task.ID, err
 }
 
-func (r *TaskRepository) GetTask(ctx context.Context, id string) (models.Task, error) {
-	var task models.Task
-	var payload []byte
+// CreateTaskOnce - idempotency_key va/yoki unique_key li taskni yaratish. Shu yaratuvchida
+// retention ichida shu idempotency kalitli task, yoki shu unique_key li pending/processing
+// task bo'lsa yangisi yozilmaydi va mavjudi false bilan qaytadi.
+func (r *TaskRepository) CreateTaskOnce(ctx context.Context, task models.Task, retention time.Duration) (models.Task, bool, error) {
+	task.ID = uuid.New().String()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Task{}, false, err
+	}
+	defer tx.Rollback()
+
+	// Muddati o'tgan kalitni bo'shatish
+	if task.IdempotencyKey != nil {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET idempotency_key = NULL
+			WHERE creator_id = $1 AND idempotency_key = $2
+				AND created_at < NOW() - $3 * INTERVAL '1 millisecond'`,
+			task.CreatorID, task.IdempotencyKey, retention.Milliseconds(),
+		); err != nil {
+			return models.Task{}, false, fmt.Errorf("eski idempotency kalitini tozalashda xato: %w", err)
+		}
+	}
+
+	// Conflict target yo'q: idempotency va unique indekslarining ikkalasi ham tekshiriladi
+	res, err := tx.ExecContext(ctx, insertTaskQuery+` ON CONFLICT DO NOTHING`, insertTaskArgs(task)...)
+	if err != nil {
+		return models.Task{}, false, fmt.Errorf("taskni saqlashda xato: %w", err)
+	}
+
+	created := true
+	if n, _ := res.RowsAffected(); n == 0 {
+		// Takroriy so'rov: avvalgi taskni qaytarish (idempotency mosligi ustun)
+		created = false
+		task, err = scanTask(tx.QueryRowContext(ctx, `
+			SELECT `+taskColumns+`
+			FROM tasks
+			WHERE (creator_id = $1 AND idempotency_key = $2)
+				OR (unique_key = $3 AND status IN ('pending', 'processing') AND deleted_at IS NULL)
+			ORDER BY (creator_id = $1 AND idempotency_key IS NOT DISTINCT FROM $2) DESC
+			LIMIT 1`,
+			task.CreatorID, task.IdempotencyKey, task.UniqueKey,
+		))
+		if errors.Is(err, sql.ErrNoRows) {
+			// Mos task shu orada yakunlandi: so'rovni qayta yuborish mumkin
+			return models.Task{}, false, storage.ErrTaskNotFound
+		}
+		if err != nil {
+			return models.Task{}, false, fmt.Errorf("mavjud taskni olishda xato: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, false, err
+	}
+	return task, created, nil
+}
 
+func (r *TaskRepository) GetTask(ctx context.Context, id string) (models.Task, error) {
 	query := `
-		SELECT 
-			id, creator_id, user_id, title, priority, status, 
-			can_user_change_status, payload, retries, max_retries,
-			scheduled_at, created_at, updated_at, deleted_at
-		FROM tasks 
+		SELECT ` + taskColumns + `
+		FROM tasks
 		WHERE id = $1 AND deleted_at IS NULL`
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&task.ID,
-		&task.CreatorID,
-		&task.UserID,
-		&task.Title,
-		&task.Priority,
-		&task.Status,
-		&task.CanUserChangeStatus,
-		&payload,
-		&task.Retries,
-		&task.MaxRetries,
-		&task.ScheduledAt,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-		&task.DeletedAt,
-	)
-
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
 	if errors.Is(err, sql.ErrNoRows) {
-		return models.Task{}, fmt.Errorf("task topilmadi")
+		return models.Task{}, storage.ErrTaskNotFound
 	}
 	if err != nil {
 		return models.Task{}, fmt.Errorf("taskni olishda xato: %w", err)
 	}
 
-	json.Unmarshal(payload, &task.Payload)
 	return task, nil
 }
 
-func (r *TaskRepository) UpdateTask(ctx context.Context, task models.Task) error {
-	payload, _ := json.Marshal(task.Payload)
-
-	query := `
+// updateTaskSet - UpdateTask va UpdateClaimedTask uchun umumiy SET qismi (updateTaskArgs tartibida)
+const updateTaskSet = `
 		UPDATE tasks SET
 			title = $2,
 			priority = $3,
@@ -105,10 +222,16 @@ func (r *TaskRepository) UpdateTask(ctx context.Context, task models.Task) error
 			retries = $7,
 			max_retries = $8,
 			scheduled_at = $9,
-			updated_at = $10
-		WHERE id = $1 AND deleted_at IS NULL`
+			next_retry_at = $10,
+			claimed_by = $11,
+			lease_expires_at = $12,
+			updated_at = $13,
+			dead_lettered_at = CASE WHEN $4 = 'failed' THEN COALESCE(dead_lettered_at, $13) ELSE NULL END`
 
-	_, err := r.db.ExecContext(ctx, query,
+func updateTaskArgs(task models.Task) []interface{} {
+	payload, _ := json.Marshal(task.Payload)
+
+	return []interface{}{
 		task.ID,
 		task.Title,
 		task.Priority,
@@ -118,10 +241,44 @@ func (r *TaskRepository) UpdateTask(ctx context.Context, task models.Task) error
 		task.Retries,
 		task.MaxRetries,
 		task.ScheduledAt,
+		task.NextRetryAt,
+		task.ClaimedBy,
+		task.LeaseExpiresAt,
 		time.Now(),
-	)
+	}
+}
 
-	return err
+func (r *TaskRepository) UpdateTask(ctx context.Context, task models.Task) error {
+	query := updateTaskSet + `
+		WHERE id = $1 AND deleted_at IS NULL
+			-- Bekor qilingan task workerning kechikkan yozuvi bilan qayta tiklanmaydi
+			AND status <> 'cancelled'`
+
+	_, err := r.db.ExecContext(ctx, query, updateTaskArgs(task)...)
+	return uniqueKeyConflict(err)
+}
+
+// UpdateClaimedTask - worker natijasini yozish: task hali shu workerda bajarilayotgan
+// bo'lsagina. Reaper qaytargan va boshqa worker olgan, bekor qilingan yoki o'chirilgan
+// taskka kechikkan worker yozmaydi - ErrTaskNotOwned qaytadi.
+func (r *TaskRepository) UpdateClaimedTask(ctx context.Context, task models.Task, workerID string) error {
+	query := updateTaskSet + `
+		WHERE id = $1 AND deleted_at IS NULL
+			AND status = 'processing'
+			AND claimed_by = $14`
+
+	res, err := r.db.ExecContext(ctx, query, append(updateTaskArgs(task), workerID)...)
+	if err != nil {
+		return fmt.Errorf("taskni yangilashda xato: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("taskni yangilashda xato: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrTaskNotOwned
+	}
+	return nil
 }
 
 func (r *TaskRepository) DeleteTask(ctx context.Context, id string) error {
@@ -137,11 +294,8 @@ func (r *TaskRepository) ListTasks(ctx context.Context, filters map[string]inter
 	argIDx := 1
 
 	baseQuery := `
-		SELECT 
-			id, creator_id, user_id, title, priority, status, 
-			can_user_change_status, payload, retries, max_retries,
-			scheduled_at, created_at, updated_at, deleted_at
-		FROM tasks 
+		SELECT ` + taskColumns + `
+		FROM tasks
 		WHERE deleted_at IS NULL`
 
 	for key, val := range filters {
@@ -158,6 +312,14 @@ func (r *TaskRepository) ListTasks(ctx context.Context, filters map[string]inter
 			where = append(where, fmt.Sprintf("status = $%d", argIDx))
 			args = append(args, val)
 			argIDx++
+		case "batch_id":
+			where = append(where, fmt.Sprintf("batch_id = $%d", argIDx))
+			args = append(args, val)
+			argIDx++
+		case "queue":
+			where = append(where, fmt.Sprintf("queue = $%d", argIDx))
+			args = append(args, val)
+			argIDx++
 		}
 	}
 
@@ -175,29 +337,10 @@ func (r *TaskRepository) ListTasks(ctx context.Context, filters map[string]inter
 	defer rows.Close()
 
 	for rows.Next() {
-		var task models.Task
-		var payload []byte
-
-		if err := rows.Scan(
-			&task.ID,
-			&task.CreatorID,
-			&task.UserID,
-			&task.Title,
-			&task.Priority,
-			&task.Status,
-			&task.CanUserChangeStatus,
-			&payload,
-			&task.Retries,
-			&task.MaxRetries,
-			&task.ScheduledAt,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-			&task.DeletedAt,
-		); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		json.Unmarshal(payload, &task.Payload)
 		tasks = append(tasks, task)
 	}
 
@@ -206,8 +349,8 @@ func (r *TaskRepository) ListTasks(ctx context.Context, filters map[string]inter
 
 func (r *TaskRepository) UpdateTaskStatus(ctx context.Context, taskID string, status string) error {
 	query := `
-        UPDATE tasks 
-        SET status = $1, updated_at = $2 
+        UPDATE tasks
+        SET status = $1, updated_at = $2
         WHERE id = $3 AND deleted_at IS NULL`
 
 	_, err := r.db.ExecContext(ctx, query,
@@ -218,3 +361,416 @@ func (r *TaskRepository) UpdateTaskStatus(ctx context.Context, taskID string, st
 
 	return err
 }
+
+// readyCondition - pending task hozir bajarilishi mumkin: vaqti kelgan va
+// barcha ota tasklari muvaffaqiyatli tugagan
+const readyCondition = `
+				status = 'pending'
+				AND deleted_at IS NULL
+				AND (scheduled_at IS NULL OR scheduled_at <= NOW())
+				AND (next_retry_at IS NULL OR next_retry_at <= NOW())
+				AND NOT EXISTS (
+					-- Barcha ota tasklar muvaffaqiyatli tugagan bo'lishi kerak
+					SELECT 1 FROM task_dependencies d
+					JOIN tasks p ON p.id = d.depends_on
+					WHERE d.task_id = tasks.id AND p.status <> 'completed'
+				)`
+
+// ClaimTask - bajarishga tayyor eng muhim taskni "processing" holatiga o'tkazib olish.
+// FOR UPDATE SKIP LOCKED tufayli bir nechta worker (va bir nechta instance)
+// bitta taskni ikki marta olmaydi.
+//
+// Barcha kutayotgan tasklarni saralamaslik uchun har bir (navbat, priority) juftligidan
+// faqat eng uzoq kutgan task idx_tasks_queue orqali olinadi va qulflanadi: bir priority
+// ichida aging eskisini baribir oldinroq qo'yadi. Shu ko'pi bilan 5 x navbatlar soni
+// nomzoddan pickClaim navbat tartibi va aging hisobga olingan priority bo'yicha eng
+// muhimini tanlaydi va u shu tranzaksiyada band qilinadi.
+func (r *TaskRepository) ClaimTask(ctx context.Context, opts storage.ClaimOptions) (models.Task, error) {
+	candidatesQuery := `
+		WITH full_types AS (` + fullTypesQuery + `)
+		SELECT c.id, q.pos, p.priority, c.ready_at, NOW()
+		-- Navbatlar opts.Queues tartibida: birinchisi bo'sh bo'lsa keyingisidan olinadi
+		FROM unnest(CASE WHEN cardinality($2::text[]) = 0
+				THEN ARRAY(SELECT DISTINCT queue::text FROM tasks WHERE status = 'pending' AND deleted_at IS NULL)
+				ELSE $2::text[] END) WITH ORDINALITY AS q(name, pos)
+		CROSS JOIN generate_series(1, 5) AS p(priority)
+		CROSS JOIN LATERAL (
+			SELECT id, ` + readyAtExpr + ` AS ready_at
+			FROM tasks
+			WHERE queue = q.name
+				AND priority = p.priority
+				AND ` + readyCondition + `
+				AND NOT (type = ANY($1::text[] || (SELECT types FROM full_types)))
+			ORDER BY ` + readyAtExpr + `
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		) c`
+
+	claimQuery := `
+		UPDATE tasks SET
+			status = 'processing',
+			claimed_by = $2,
+			lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond',
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + taskColumns
+
+	// nil massiv NULL bo'lib qoladi va NOT (type = ANY(NULL)) hech bir taskni o'tkazmaydi
+	exclude := append([]string{}, opts.ExcludeTypes...)
+	queues := opts.Queues
+	if queues == nil {
+		queues = []string{}
+	}
+
+	claim := func(tx *sql.Tx) (models.Task, error) {
+		rows, err := tx.QueryContext(ctx, candidatesQuery, pq.Array(exclude), pq.Array(queues))
+		if err != nil {
+			return models.Task{}, err
+		}
+		defer rows.Close()
+
+		var (
+			candidates []claimCandidate
+			now        time.Time
+		)
+		for rows.Next() {
+			var c claimCandidate
+			if err := rows.Scan(&c.ID, &c.QueuePos, &c.Priority, &c.ReadyAt, &now); err != nil {
+				return models.Task{}, err
+			}
+			candidates = append(candidates, c)
+		}
+		if err := rows.Err(); err != nil {
+			return models.Task{}, err
+		}
+
+		chosen, ok := pickClaim(candidates, opts.PriorityAging, now)
+		if !ok {
+			return models.Task{}, sql.ErrNoRows
+		}
+		return scanTask(tx.QueryRowContext(ctx, claimQuery, chosen.ID, opts.WorkerID, opts.Lease.Milliseconds()))
+	}
+
+	// full_types tekshiruvidan keyin boshqa instance oxirgi joyni egallagan bo'lsa
+	// shu tur chiqarib tashlanib qayta uriniladi
+	for range maxClaimAttempts {
+		task, err := r.claimWithinLimit(ctx, claim)
+		if errors.Is(err, storage.ErrTypeLimited) {
+			exclude = append(exclude, task.Type)
+			continue
+		}
+		return task, err
+	}
+	return models.Task{}, storage.ErrNoTask
+}
+
+// maxClaimAttempts - ClaimTask da tur cheklovi sababli qayta urinishlar soni
+const maxClaimAttempts = 3
+
+// fullTypesQuery - barcha instancelar bo'yicha max_concurrency ga yetgan turlar
+const fullTypesQuery = `
+			SELECT COALESCE(array_agg(l.type::text), '{}') AS types
+			FROM task_type_limits l
+			WHERE l.max_concurrency > 0
+				AND l.max_concurrency <= (
+					SELECT COUNT(*) FROM tasks r
+					WHERE r.type = l.type AND r.status = 'processing' AND r.deleted_at IS NULL
+				)`
+
+// claimWithinLimit - claim so'rovini tranzaksiyada bajarish va band qilingan task turi
+// max_concurrency dan oshmaganini tekshirish. Cheklov qatori qulflanadi, shuning uchun
+// bir turni bir vaqtda band qilayotgan tranzaksiyalar navbat bilan sanaydi va har biri
+// oldingilarining commit qilingan claimlarini ko'radi. Oshib ketsa claim bekor qilinadi
+// va task (faqat turi uchun) bilan birga storage.ErrTypeLimited qaytadi.
+// claim taskni tx ichida band qiladi; band qiladigan task bo'lmasa sql.ErrNoRows.
+func (r *TaskRepository) claimWithinLimit(ctx context.Context, claim func(tx *sql.Tx) (models.Task, error)) (models.Task, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Task{}, fmt.Errorf("tranzaksiya ochishda xato: %w", err)
+	}
+	defer tx.Rollback()
+
+	task, err := claim(tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, storage.ErrNoTask
+	}
+	if err != nil {
+		return models.Task{}, fmt.Errorf("taskni band qilishda xato: %w", err)
+	}
+
+	var limit int
+	err = tx.QueryRowContext(ctx, `
+		SELECT max_concurrency FROM task_type_limits
+		WHERE type = $1 AND max_concurrency > 0
+		FOR UPDATE`, task.Type,
+	).Scan(&limit)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, fmt.Errorf("tur cheklovini olishda xato: %w", err)
+	}
+	if err == nil {
+		// Qulfdan keyingi yangi so'rov: boshqa tranzaksiyalar commit qilgan claimlar va
+		// shu tranzaksiyadagi claim ham sanaladi
+		var running int
+		err = tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM tasks
+			WHERE type = $1 AND status = 'processing' AND deleted_at IS NULL`, task.Type,
+		).Scan(&running)
+		if err != nil {
+			return models.Task{}, fmt.Errorf("bajarilayotgan tasklarni sanashda xato: %w", err)
+		}
+		if running > limit {
+			return models.Task{Type: task.Type}, storage.ErrTypeLimited
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.Task{}, fmt.Errorf("taskni band qilishda xato: %w", err)
+	}
+	return task, nil
+}
+
+// ClaimTaskByID - aynan shu taskni, u bajarishga tayyor bo'lsa, band qilish.
+// Tashqi navbat (Redis) xabaridagi task allaqachon olingan, bekor qilingan yoki
+// hali tayyor bo'lmasa ErrNoTask, turi max_concurrency ga yetgan bo'lsa ErrTypeLimited qaytadi.
+func (r *TaskRepository) ClaimTaskByID(ctx context.Context, taskID string, opts storage.ClaimOptions) (models.Task, error) {
+	query := `
+		UPDATE tasks SET
+			status = 'processing',
+			claimed_by = $2,
+			lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond',
+			updated_at = NOW()
+		WHERE id = $1 AND ` + readyCondition + `
+		RETURNING ` + taskColumns
+
+	task, err := r.claimWithinLimit(ctx, func(tx *sql.Tx) (models.Task, error) {
+		return scanTask(tx.QueryRowContext(ctx, query, taskID, opts.WorkerID, opts.Lease.Milliseconds()))
+	})
+	if errors.Is(err, storage.ErrTypeLimited) {
+		return models.Task{}, err
+	}
+	return task, err
+}
+
+// TouchStaleReady - older dan beri o'zgarmagan tayyor pending tasklarning updated_at ini
+// yangilab qaytaradi. Tashqi navbatdagi xabari yo'qolgan tasklarni qayta yuborish uchun:
+// bir task har older oralig'ida ko'pi bilan bir marta qaytadi.
+func (r *TaskRepository) TouchStaleReady(ctx context.Context, queues []string, older time.Duration, limit int) ([]models.Task, error) {
+	query := `
+		UPDATE tasks SET updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM tasks
+			WHERE ` + readyCondition + `
+				AND queue = ANY($1)
+				AND updated_at < NOW() - $2 * INTERVAL '1 millisecond'
+			ORDER BY updated_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + taskColumns
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(queues), older.Milliseconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("yo'qolgan tasklarni qidirishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// ListDelayedTasks - hali vaqti kelmagan, lekin until gacha bajarilishi kerak bo'lgan pending tasklar
+func (r *TaskRepository) ListDelayedTasks(ctx context.Context, until time.Time) ([]models.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE status = 'pending'
+			AND deleted_at IS NULL
+			AND GREATEST(scheduled_at, next_retry_at) > NOW()
+			AND GREATEST(scheduled_at, next_retry_at) <= $1
+		ORDER BY GREATEST(scheduled_at, next_retry_at)`
+
+	rows, err := r.db.QueryContext(ctx, query, until)
+	if err != nil {
+		return nil, fmt.Errorf("rejalashtirilgan tasklarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
+
+// ReleaseTask - workerID band qilgan "processing" taskni qayta "pending" ga qaytarish
+// (masalan worker to'xtatilganda task yakunlanmay qolsa). Reaper taskni qaytarib,
+// boshqa worker olgan bo'lsa unga tegilmaydi - ErrTaskNotOwned qaytadi.
+func (r *TaskRepository) ReleaseTask(ctx context.Context, taskID, workerID string) error {
+	query := `
+		UPDATE tasks SET
+			status = 'pending',
+			claimed_by = NULL,
+			lease_expires_at = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'processing' AND claimed_by = $2 AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, taskID, workerID)
+	if err != nil {
+		return fmt.Errorf("taskni navbatga qaytarishda xato: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("taskni navbatga qaytarishda xato: %w", err)
+	}
+	if n == 0 {
+		return storage.ErrTaskNotOwned
+	}
+	return nil
+}
+
+// ExtendLease - worker hali ishlayotganini bildirish (heartbeat).
+// Task endi shu workerga tegishli bo'lmasa (reaper qaytargan, bekor qilingan) false qaytaradi.
+func (r *TaskRepository) ExtendLease(ctx context.Context, taskID, workerID string, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE tasks SET
+			lease_expires_at = NOW() + $3 * INTERVAL '1 millisecond'
+		WHERE id = $1 AND claimed_by = $2 AND status = 'processing' AND deleted_at IS NULL`
+
+	res, err := r.db.ExecContext(ctx, query, taskID, workerID, lease.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// reapExhausted - reaper qaytargan taskning urinishlari tugaganmi
+// ("none" siyosatli task birinchi xatodayoq failed bo'ladi)
+const reapExhausted = `(retries + 1 >= max_retries OR retry_policy->>'strategy' = 'none')`
+
+// ReapExpiredLeases - lease muddati o'tgan (worker qulab tushgan) tasklarni qaytarish:
+// qayta urinishlar qolgan bo'lsa nextRetry vaqtida bajariladigan "pending", aks holda
+// "failed" (dead-letter). nextRetry retries oshirilgan task bilan chaqiriladi, shuning
+// uchun kechikish worker xatosidagi kabi task siyosati bo'yicha hisoblanadi.
+// Lease yo'qolgani ham xatolar tarixiga yoziladi.
+func (r *TaskRepository) ReapExpiredLeases(ctx context.Context, nextRetry func(task models.Task) time.Time) ([]models.Task, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("tranzaksiya ochishda xato: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Bir vaqtda ishlayotgan boshqa instancelarning reaperlari bilan to'qnashmaslik uchun
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+taskColumns+`
+		FROM tasks
+		WHERE status = 'processing'
+			AND deleted_at IS NULL
+			AND (lease_expires_at IS NULL OR lease_expires_at < NOW())
+		FOR UPDATE SKIP LOCKED`)
+	if err != nil {
+		return nil, fmt.Errorf("muddati o'tgan tasklarni olishda xato: %w", err)
+	}
+	var expired []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		expired = append(expired, task)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE tasks SET
+			retries = retries + 1,
+			status = CASE WHEN ` + reapExhausted + ` THEN 'failed' ELSE 'pending' END,
+			dead_lettered_at = CASE WHEN ` + reapExhausted + ` THEN NOW() ELSE NULL END,
+			next_retry_at = CASE WHEN ` + reapExhausted + ` THEN next_retry_at ELSE $3 END,
+			last_error = $2,
+			error_history = error_history || jsonb_build_array(jsonb_build_object(
+				'attempt', retries + 1, 'error', $2::TEXT, 'at', NOW()
+			)),
+			claimed_by = NULL,
+			lease_expires_at = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + taskColumns
+
+	tasks := make([]models.Task, 0, len(expired))
+	for _, task := range expired {
+		task.Retries++
+		reaped, err := scanTask(tx.QueryRowContext(ctx, query,
+			task.ID, "lease muddati o'tdi: worker javob bermadi", nextRetry(task),
+		))
+		if err != nil {
+			return nil, fmt.Errorf("muddati o'tgan taskni qaytarishda xato: %w", err)
+		}
+		tasks = append(tasks, reaped)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("muddati o'tgan tasklarni qaytarishda xato: %w", err)
+	}
+	return tasks, nil
+}
+
+// CancelTask - taskni bekor qilish. Claim tozalanmaydi: bajarayotgan worker
+// keyingi heartbeatda lease yo'qolganini ko'rib handlerni to'xtatadi.
+func (r *TaskRepository) CancelTask(ctx context.Context, taskID, cancelledBy, reason string) (models.Task, error) {
+	query := `
+		UPDATE tasks SET
+			status = 'cancelled',
+			cancel_reason = NULLIF($2, ''),
+			cancelled_by = $3,
+			cancelled_at = NOW(),
+			next_retry_at = NULL,
+			updated_at = NOW()
+		WHERE id = $1
+			AND status IN ('pending', 'processing')
+			AND deleted_at IS NULL
+		RETURNING ` + taskColumns
+
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, taskID, reason, cancelledBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, storage.ErrTaskNotFound
+	}
+	if err != nil {
+		return models.Task{}, fmt.Errorf("taskni bekor qilishda xato: %w", err)
+	}
+	return task, nil
+}
+
+// RecordTaskError - muvaffaqiyatsiz urinishni last_error va error_history ga yozish
+func (r *TaskRepository) RecordTaskError(ctx context.Context, taskID string, attempt int, errMsg string) error {
+	query := `
+		UPDATE tasks SET
+			last_error = $2,
+			error_history = error_history || jsonb_build_array(jsonb_build_object(
+				'attempt', $3::INT, 'error', $2::TEXT, 'at', NOW()
+			))
+		WHERE id = $1 AND deleted_at IS NULL`
+
+	_, err := r.db.ExecContext(ctx, query, taskID, errMsg, attempt)
+	return err
+}
+
+// readyAtExpr - task bajarishga tayyor bo'lgan vaqt. idx_tasks_queue dagi ifoda bilan
+// aynan bir xil bo'lishi kerak, aks holda index ishlatilmaydi.
+const readyAtExpr = "COALESCE(GREATEST(scheduled_at, next_retry_at), created_at)"
@@ -0,0 +1,444 @@
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// openTestDB - migratsiya qilingan test bazasiga ulanish (TEST_PDB_URL).
+// O'rnatilmagan bo'lsa test o'tkazib yuboriladi.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_PDB_URL")
+	if url == "" {
+		t.Skip("TEST_PDB_URL o'rnatilmagan")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	return db
+}
+
+// claimEnv - alohida navbat va foydalanuvchi: testlar bazadagi boshqa tasklarga tegmaydi
+type claimEnv struct {
+	db     *sql.DB
+	repo   storage.ITaskStorage
+	userID string
+	queue  string
+}
+
+func newClaimEnv(t *testing.T) *claimEnv {
+	t.Helper()
+
+	db := openTestDB(t)
+	ctx := context.Background()
+	suffix := uuid.New().String()[:8]
+
+	var userID string
+	err := db.QueryRowContext(ctx, `
+		INSERT INTO users (email, name, surname, password_hash)
+		VALUES ($1, 'Test', 'Claim', 'x')
+		RETURNING id`, "claim-"+suffix+"@test.local",
+	).Scan(&userID)
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	env := &claimEnv{db: db, repo: NewTaskRepository(db), userID: userID, queue: "test-claim-" + suffix}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM tasks WHERE queue = $1`, env.queue)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+	return env
+}
+
+func (e *claimEnv) add(t *testing.T, title string, priority int, age time.Duration) string {
+	t.Helper()
+	return e.addTyped(t, title, "test", priority, age)
+}
+
+func (e *claimEnv) addTyped(t *testing.T, title, taskType string, priority int, age time.Duration) string {
+	t.Helper()
+
+	created := time.Now().Add(-age)
+	id, err := e.repo.CreateTask(context.Background(), models.Task{
+		CreatorID:  e.userID,
+		UserID:     e.userID,
+		Title:      title,
+		Type:       taskType,
+		Priority:   priority,
+		Queue:      e.queue,
+		Status:     "pending",
+		Payload:    []byte(`{}`),
+		MaxRetries: 3,
+		CreatedAt:  created,
+		UpdatedAt:  created,
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	return id
+}
+
+func (e *claimEnv) claim(aging time.Duration, worker string) (models.Task, error) {
+	return e.repo.ClaimTask(context.Background(), storage.ClaimOptions{
+		PriorityAging: aging,
+		Queues:        []string{e.queue},
+		WorkerID:      worker,
+		Lease:         time.Minute,
+	})
+}
+
+// claimTitles - navbat bo'shaguncha tasklarni olib, sarlavhalarini tartib bilan qaytarish
+func (e *claimEnv) claimTitles(t *testing.T, aging time.Duration) []string {
+	t.Helper()
+
+	var titles []string
+	for {
+		task, err := e.claim(aging, "worker-1")
+		if errors.Is(err, storage.ErrNoTask) {
+			return titles
+		}
+		if err != nil {
+			t.Fatalf("ClaimTask: %v", err)
+		}
+		titles = append(titles, task.Title)
+	}
+}
+
+func TestClaimTaskOrder(t *testing.T) {
+	e := newClaimEnv(t)
+
+	e.add(t, "p3", 3, 3*time.Minute)
+	e.add(t, "p5-new", 5, 0)
+	e.add(t, "p5-old", 5, 2*time.Minute)
+	e.add(t, "p1", 1, time.Hour)
+
+	got := e.claimTitles(t, 0)
+	want := []string{"p5-old", "p5-new", "p3", "p1"}
+	if len(got) != len(want) {
+		t.Fatalf("claimed %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("claimed %v, want %v", got, want)
+		}
+	}
+}
+
+func TestClaimTaskPriorityAging(t *testing.T) {
+	e := newClaimEnv(t)
+
+	// aging=1m: 10 daqiqa kutgan p1 taskning priority si 5 ga yetadi va u eskiroq,
+	// 1 daqiqa kutgani esa faqat 2 ga ko'tariladi
+	e.add(t, "p1-aged", 1, 10*time.Minute)
+	e.add(t, "p1-young", 1, time.Minute+time.Second)
+	e.add(t, "p5-a", 5, 2*time.Second)
+	e.add(t, "p5-b", 5, time.Second)
+
+	got := e.claimTitles(t, time.Minute)
+	want := []string{"p1-aged", "p5-a", "p5-b", "p1-young"}
+	if len(got) != len(want) {
+		t.Fatalf("claimed %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("claimed %v, want %v", got, want)
+		}
+	}
+}
+
+func TestClaimTaskConcurrent(t *testing.T) {
+	e := newClaimEnv(t)
+
+	const (
+		workers = 8
+		high    = 40
+		low     = 40
+		aged    = 5
+	)
+
+	priority := make(map[string]string)
+	for i := 0; i < high; i++ {
+		priority[e.add(t, "p5", 5, 0)] = "p5"
+	}
+	for i := 0; i < low; i++ {
+		priority[e.add(t, "p1", 1, 0)] = "p1"
+	}
+	for i := 0; i < aged; i++ {
+		priority[e.add(t, "p1-aged", 1, time.Hour)] = "p1-aged"
+	}
+
+	// Har bir claim tartib raqami bilan yoziladi; parallel workerlar orasida
+	// tartib ko'pi bilan workers-1 ta o'ringa siljishi mumkin
+	var (
+		mu    sync.Mutex
+		order []string
+		seen  = make(map[string]int)
+		wg    sync.WaitGroup
+		errCh = make(chan error, workers)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+			for {
+				task, err := e.claim(time.Minute, worker)
+				if errors.Is(err, storage.ErrNoTask) {
+					return
+				}
+				if err != nil {
+					errCh <- err
+					return
+				}
+				mu.Lock()
+				order = append(order, task.ID)
+				seen[task.ID]++
+				mu.Unlock()
+			}
+		}(uuid.New().String())
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+
+	if len(order) != len(priority) {
+		t.Fatalf("claimed %d tasks, want %d", len(order), len(priority))
+	}
+	for id, n := range seen {
+		if n != 1 {
+			t.Fatalf("task %s claimed %d times", id, n)
+		}
+	}
+
+	lastHigh, earlyLow := 0, 0
+	for i, id := range order {
+		switch priority[id] {
+		case "p5":
+			lastHigh = i
+		case "p1-aged":
+			// Uzoq kutgan tasklar yangi p5 tasklar orqasida qolib ketmaydi
+			if i >= aged+workers {
+				t.Errorf("aged task claimed at position %d, want < %d", i, aged+workers)
+			}
+		}
+	}
+	for _, id := range order[:lastHigh] {
+		if priority[id] == "p1" {
+			earlyLow++
+		}
+	}
+	if earlyLow >= workers {
+		t.Errorf("%d fresh p1 tasks claimed before the last p5 task, want < %d", earlyLow, workers)
+	}
+}
+
+func TestClaimTaskTypeLimitAcrossInstances(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	taskType := e.queue + "-limited"
+	limits := NewTaskTypeLimitRepository(e.db)
+	if _, err := limits.UpsertLimit(ctx, models.TaskTypeLimit{Type: taskType, MaxConcurrency: 2}); err != nil {
+		t.Fatalf("UpsertLimit: %v", err)
+	}
+	t.Cleanup(func() { limits.DeleteLimit(context.Background(), taskType) })
+
+	for i := 0; i < 6; i++ {
+		e.addTyped(t, "limited", taskType, 5, 0)
+	}
+	for i := 0; i < 3; i++ {
+		e.add(t, "free", 1, 0)
+	}
+
+	// Har bir goroutine alohida instance: jarayon ichidagi limiter hech narsani bilmaydi.
+	// Tasklar bajarilmaydi (processing da qoladi), shuning uchun cheklangan turdan
+	// navbat bo'shaguncha ham faqat 2 tasi olinishi kerak.
+	var (
+		mu      sync.Mutex
+		claimed = make(map[string]int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(worker string) {
+			defer wg.Done()
+			for {
+				task, err := e.claim(0, worker)
+				if errors.Is(err, storage.ErrNoTask) {
+					return
+				}
+				if err != nil {
+					t.Errorf("ClaimTask: %v", err)
+					return
+				}
+				mu.Lock()
+				claimed[task.Type]++
+				mu.Unlock()
+			}
+		}(uuid.New().String())
+	}
+	wg.Wait()
+
+	if claimed[taskType] != 2 {
+		t.Fatalf("claimed %d limited tasks, want max_concurrency 2", claimed[taskType])
+	}
+	if claimed["test"] != 3 {
+		t.Fatalf("claimed %d unlimited tasks, want 3", claimed["test"])
+	}
+
+	running, err := limits.RunningCounts(ctx)
+	if err != nil {
+		t.Fatalf("RunningCounts: %v", err)
+	}
+	if running[taskType] != 2 {
+		t.Fatalf("RunningCounts[%s] = %d, want 2", taskType, running[taskType])
+	}
+}
+
+func TestUpdateClaimedTaskRequiresOwnership(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	e.add(t, "owned", 3, 0)
+	task, err := e.claim(0, "worker-a")
+	if err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+
+	// Lease tugab task boshqa workerga o'tgan: eski workerning natijasi yozilmaydi
+	done := task
+	done.Status = "completed"
+	done.ClaimedBy = nil
+	if err := e.repo.UpdateClaimedTask(ctx, done, "worker-b"); !errors.Is(err, storage.ErrTaskNotOwned) {
+		t.Fatalf("UpdateClaimedTask by other worker = %v, want ErrTaskNotOwned", err)
+	}
+
+	if err := e.repo.UpdateClaimedTask(ctx, done, "worker-a"); err != nil {
+		t.Fatalf("UpdateClaimedTask by owner = %v", err)
+	}
+
+	// Task yakunlangan: takroriy yozuv ham rad etiladi
+	if err := e.repo.UpdateClaimedTask(ctx, done, "worker-a"); !errors.Is(err, storage.ErrTaskNotOwned) {
+		t.Fatalf("UpdateClaimedTask after completion = %v, want ErrTaskNotOwned", err)
+	}
+}
+
+func TestReapExpiredLeasesBacksOff(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	id := e.add(t, "crashed", 3, 0)
+	if _, err := e.repo.ClaimTask(ctx, storage.ClaimOptions{
+		Queues:   []string{e.queue},
+		WorkerID: "dead-worker",
+		Lease:    time.Millisecond,
+	}); err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	next := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	var seenRetries int
+	tasks, err := e.repo.ReapExpiredLeases(ctx, func(task models.Task) time.Time {
+		if task.ID == id {
+			seenRetries = task.Retries
+		}
+		return next
+	})
+	if err != nil {
+		t.Fatalf("ReapExpiredLeases: %v", err)
+	}
+
+	var reaped *models.Task
+	for i := range tasks {
+		if tasks[i].ID == id {
+			reaped = &tasks[i]
+		}
+	}
+	if reaped == nil {
+		t.Fatalf("task %s was not reaped", id)
+	}
+	if seenRetries != 1 {
+		t.Fatalf("nextRetry called with retries = %d, want 1", seenRetries)
+	}
+	if reaped.Status != "pending" || reaped.Retries != 1 || reaped.NextRetryAt == nil || !reaped.NextRetryAt.Equal(next) {
+		t.Fatalf("reaped = status %s, retries %d, next_retry_at %v; want pending, 1, %v",
+			reaped.Status, reaped.Retries, reaped.NextRetryAt, next)
+	}
+
+	// Kechikish tugamaguncha task qayta olinmaydi
+	if _, err := e.claim(0, "worker-b"); !errors.Is(err, storage.ErrNoTask) {
+		t.Fatalf("ClaimTask during backoff = %v, want ErrNoTask", err)
+	}
+}
+
+func TestUpdateTaskUniqueConflict(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	key := uuid.New().String()
+	failedID := e.add(t, "failed", 3, 0)
+	pendingID := e.add(t, "pending", 3, 0)
+	for id, status := range map[string]string{failedID: "failed", pendingID: "pending"} {
+		if _, err := e.db.ExecContext(ctx, `UPDATE tasks SET status = $2, unique_key = $3 WHERE id = $1`, id, status, key); err != nil {
+			t.Fatalf("set unique_key: %v", err)
+		}
+	}
+
+	// Xuddi shunday task navbatda: failed taskni pending ga qaytarib bo'lmaydi
+	task, err := e.repo.GetTask(ctx, failedID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	task.Status = "pending"
+	if err := e.repo.UpdateTask(ctx, task); !errors.Is(err, storage.ErrUniqueConflict) {
+		t.Fatalf("UpdateTask = %v, want ErrUniqueConflict", err)
+	}
+}
+
+func TestReleaseTaskRequiresOwnership(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	e.add(t, "drained", 3, 0)
+	task, err := e.claim(0, "worker-b")
+	if err != nil {
+		t.Fatalf("ClaimTask: %v", err)
+	}
+
+	// Reaper taskni worker-a dan olib worker-b ga bergan: worker-a uni qaytara olmaydi
+	if err := e.repo.ReleaseTask(ctx, task.ID, "worker-a"); !errors.Is(err, storage.ErrTaskNotOwned) {
+		t.Fatalf("ReleaseTask by previous owner = %v, want ErrTaskNotOwned", err)
+	}
+	if err := e.repo.ReleaseTask(ctx, task.ID, "worker-b"); err != nil {
+		t.Fatalf("ReleaseTask by owner = %v", err)
+	}
+
+	got, err := e.repo.GetTask(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if got.Status != "pending" || got.ClaimedBy != nil {
+		t.Fatalf("task = status %s, claimed_by %v; want pending, nil", got.Status, got.ClaimedBy)
+	}
+}
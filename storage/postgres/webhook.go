@@ -0,0 +1,249 @@
+// storage/postgres/webhook_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const webhookColumns = `id, user_id, url, events, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+	var w models.Webhook
+	err := row.Scan(&w.ID, &w.UserID, &w.URL, pq.Array(&w.Events), &w.CreatedAt, &w.UpdatedAt)
+	return w, err
+}
+
+const deliveryColumns = `
+			d.id, COALESCE(d.webhook_id::text, ''), COALESCE(d.task_id::text, ''), d.batch_id,
+			d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.last_status_code, d.last_error, d.created_at, d.delivered_at`
+
+func scanDelivery(row rowScanner, extra ...interface{}) (models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+
+	dest := []interface{}{
+		&d.ID,
+		&d.WebhookID,
+		&d.TaskID,
+		&d.BatchID,
+		&d.Event,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastStatusCode,
+		&d.LastError,
+		&d.CreatedAt,
+		&d.DeliveredAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d.Payload = payload
+	return d, nil
+}
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) storage.IWebhookStorage {
+	return &WebhookRepository{db: db}
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	query := `
+		INSERT INTO webhooks (user_id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + webhookColumns
+
+	created, err := scanWebhook(r.db.QueryRowContext(ctx, query,
+		webhook.UserID, webhook.URL, webhook.Secret, pq.Array(webhook.Events),
+	))
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("webhook yaratishda xato: %w", err)
+	}
+	return created, nil
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id string) (models.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Webhook{}, storage.ErrWebhookNotFound
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("webhookni olishda xato: %w", err)
+	}
+	return w, nil
+}
+
+func (r *WebhookRepository) ListWebhooks(ctx context.Context, userID string) ([]models.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE $1 = '' OR user_id::text = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("webhooklarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
+
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("webhookni o'chirishda xato: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return storage.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (r *WebhookRepository) EnqueueDeliveries(ctx context.Context, userIDs []string, taskID, event string, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (webhook_id, task_id, event, payload)
+		SELECT id, $2, $3, $4
+		FROM webhooks
+		WHERE user_id::text = ANY($1)
+			AND (cardinality(events) = 0 OR $3 = ANY(events))`
+
+	res, err := r.db.ExecContext(ctx, query, pq.Array(userIDs), taskID, event, payload)
+	if err != nil {
+		return 0, fmt.Errorf("webhook yuborishlarini yaratishda xato: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *WebhookRepository) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d SET
+			next_attempt_at = NOW() + $2 * INTERVAL '1 millisecond'
+		FROM due
+		WHERE d.id = due.id
+		-- Manzil va kalit webhookdan yoki batch on_complete hookidan olinadi
+		RETURNING ` + deliveryColumns + `,
+			COALESCE(
+				(SELECT w.url FROM webhooks w WHERE w.id = d.webhook_id),
+				(SELECT b.on_complete->>'webhook_url' FROM batches b WHERE b.id = d.batch_id),
+				''
+			),
+			COALESCE(
+				(SELECT w.secret FROM webhooks w WHERE w.id = d.webhook_id),
+				(SELECT b.hook_secret FROM batches b WHERE b.id = d.batch_id),
+				''
+			)`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("webhook yuborishlarini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.WebhookDelivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		d.URL, d.Secret = url, secret
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func (r *WebhookRepository) FinishAttempt(ctx context.Context, d models.WebhookDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET
+			status = $2,
+			attempts = $3,
+			next_attempt_at = $4,
+			last_status_code = $5,
+			last_error = $6,
+			delivered_at = $7
+		WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("webhook urinishini yozishda xato: %w", err)
+	}
+	return nil
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.webhook_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("webhook yuborishlarini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+func (r *WebhookRepository) GetBatchDelivery(ctx context.Context, batchID string) (*models.WebhookDelivery, error) {
+	query := `
+		SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		WHERE d.batch_id = $1
+		ORDER BY d.created_at DESC
+		LIMIT 1`
+
+	d, err := scanDelivery(r.db.QueryRowContext(ctx, query, batchID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("batch hooki yuborilishini olishda xato: %w", err)
+	}
+	return &d, nil
+}
@@ -0,0 +1,184 @@
+// storage/postgres/worker_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// workerCommandsChannel - yangi buyruq haqida worker jarayonlariga xabar beriladigan kanal
+const workerCommandsChannel = "worker_commands"
+
+// workerCommandRetention - bajarilgan buyruqlar shuncha vaqtdan keyin o'chiriladi
+const workerCommandRetention = 24 * time.Hour
+
+const workerCommandColumns = `id, instance_id, action, queue, workers, created_by, created_at`
+
+func scanWorkerCommand(row rowScanner) (models.WorkerCommand, error) {
+	var cmd models.WorkerCommand
+	err := row.Scan(&cmd.ID, &cmd.InstanceID, &cmd.Action, &cmd.Queue, &cmd.Workers, &cmd.CreatedBy, &cmd.CreatedAt)
+	return cmd, err
+}
+
+type WorkerRepository struct {
+	db *sql.DB
+}
+
+func NewWorkerRepository(db *sql.DB) storage.IWorkerStorage {
+	return &WorkerRepository{db: db}
+}
+
+func (r *WorkerRepository) ReportState(ctx context.Context, state models.PoolState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("worker holatini kodlashda xato: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO worker_instances (instance_id, state, reported_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (instance_id) DO UPDATE SET
+			state = EXCLUDED.state,
+			reported_at = EXCLUDED.reported_at`,
+		state.InstanceID, data,
+	)
+	if err != nil {
+		return fmt.Errorf("worker holatini yozishda xato: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkerRepository) ListStates(ctx context.Context, since time.Time) ([]models.PoolState, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT state, reported_at
+		FROM worker_instances
+		WHERE reported_at >= $1
+		ORDER BY instance_id`,
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("worker holatlarini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.PoolState
+	for rows.Next() {
+		var (
+			data       []byte
+			reportedAt time.Time
+			state      models.PoolState
+		)
+		if err := rows.Scan(&data, &reportedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("worker holatini o'qishda xato: %w", err)
+		}
+		state.ReportedAt = &reportedAt
+		list = append(list, state)
+	}
+	return list, rows.Err()
+}
+
+func (r *WorkerRepository) RemoveState(ctx context.Context, instanceID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM worker_instances WHERE instance_id = $1`, instanceID); err != nil {
+		return fmt.Errorf("worker holatini o'chirishda xato: %w", err)
+	}
+	return nil
+}
+
+func (r *WorkerRepository) SendCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.WorkerCommand{}, err
+	}
+	defer tx.Rollback()
+
+	// Eski buyruqlar kerak emas: ishlayotgan jarayonlar ularni allaqachon o'qigan
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM worker_commands WHERE created_at < NOW() - $1 * INTERVAL '1 millisecond'`,
+		workerCommandRetention.Milliseconds(),
+	); err != nil {
+		return models.WorkerCommand{}, fmt.Errorf("eski buyruqlarni o'chirishda xato: %w", err)
+	}
+
+	created, err := scanWorkerCommand(tx.QueryRowContext(ctx, `
+		INSERT INTO worker_commands (instance_id, action, queue, workers, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+workerCommandColumns,
+		cmd.InstanceID, cmd.Action, cmd.Queue, cmd.Workers, cmd.CreatedBy,
+	))
+	if err != nil {
+		return models.WorkerCommand{}, fmt.Errorf("buyruqni yozishda xato: %w", err)
+	}
+
+	// NOTIFY tranzaksiya commit bo'lganda yetkaziladi
+	if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, workerCommandsChannel, strconv.FormatInt(created.ID, 10)); err != nil {
+		return models.WorkerCommand{}, fmt.Errorf("buyruq haqida xabar berishda xato: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.WorkerCommand{}, err
+	}
+	return created, nil
+}
+
+func (r *WorkerRepository) ListCommands(ctx context.Context, instanceID string, afterID int64) ([]models.WorkerCommand, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+workerCommandColumns+`
+		FROM worker_commands
+		WHERE id > $1 AND (instance_id IS NULL OR instance_id = $2)
+		ORDER BY id`,
+		afterID, instanceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("buyruqlarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var list []models.WorkerCommand
+	for rows.Next() {
+		cmd, err := scanWorkerCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, cmd)
+	}
+	return list, rows.Err()
+}
+
+func (r *WorkerRepository) LastCommandID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := r.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM worker_commands`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("oxirgi buyruqni olishda xato: %w", err)
+	}
+	return id, nil
+}
+
+// ListenWorkerCommands - yangi buyruqlar haqidagi xabarlarni alohida ulanishda LISTEN qilish.
+// Kanaldan kelgan signal "buyruqlarni qayta o'qish kerak" degani; qayta ulanishda ham
+// signal beriladi, chunki oraliqdagi xabarlar yo'qolgan bo'lishi mumkin.
+func ListenWorkerCommands(ctx context.Context, logger *slog.Logger) (<-chan struct{}, error) {
+	signals := make(chan struct{}, 1)
+	err := listen(ctx, logger, workerCommandsChannel, func(n *pq.Notification) bool {
+		select {
+		case signals <- struct{}{}:
+		default:
+			// Oldingi signal hali o'qilmagan: buyruqlar baribir birga o'qiladi
+		}
+		return true
+	}, func() { close(signals) })
+	if err != nil {
+		return nil, err
+	}
+	return signals, nil
+}
@@ -0,0 +1,181 @@
+// storage/postgres/workflow_repository.go
+package postgres
+
+import (
+	models "asynchronous/model/db"
+	"asynchronous/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// dependantsCTE - $1 taskka bevosita yoki bilvosita bog'liq barcha tasklar
+const dependantsCTE = `
+		WITH RECURSIVE dependants AS (
+			SELECT task_id FROM task_dependencies WHERE depends_on = $1
+			UNION
+			SELECT d.task_id FROM task_dependencies d
+			JOIN dependants ON d.depends_on = dependants.task_id
+		)`
+
+type WorkflowRepository struct {
+	db *sql.DB
+}
+
+func NewWorkflowRepository(db *sql.DB) storage.IWorkflowStorage {
+	return &WorkflowRepository{db: db}
+}
+
+func (r *WorkflowRepository) CreateWorkflow(ctx context.Context, wf models.Workflow, tasks []models.Task, deps []models.TaskDependency) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO workflows (id, creator_id, name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5)`,
+		wf.ID, wf.CreatorID, wf.Name, wf.CreatedAt, wf.UpdatedAt,
+	); err != nil {
+		return fmt.Errorf("workflowni saqlashda xato: %w", err)
+	}
+
+	for _, task := range tasks {
+		if err := insertTask(ctx, tx, task); err != nil {
+			return fmt.Errorf("workflow taskini saqlashda xato: %w", err)
+		}
+	}
+
+	for _, dep := range deps {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO task_dependencies (task_id, depends_on) VALUES ($1, $2)`,
+			dep.TaskID, dep.DependsOn,
+		); err != nil {
+			return fmt.Errorf("bog'liqlikni saqlashda xato: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *WorkflowRepository) GetWorkflow(ctx context.Context, id string) (models.Workflow, error) {
+	var wf models.Workflow
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, creator_id, name, created_at, updated_at
+		FROM workflows
+		WHERE id = $1`, id,
+	).Scan(&wf.ID, &wf.CreatorID, &wf.Name, &wf.CreatedAt, &wf.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Workflow{}, storage.ErrWorkflowNotFound
+	}
+	if err != nil {
+		return models.Workflow{}, fmt.Errorf("workflowni olishda xato: %w", err)
+	}
+	return wf, nil
+}
+
+func (r *WorkflowRepository) ListWorkflowTasks(ctx context.Context, workflowID string) ([]models.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE workflow_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at`
+
+	return r.queryTasks(ctx, query, workflowID)
+}
+
+func (r *WorkflowRepository) ListDependencies(ctx context.Context, workflowID string) ([]models.TaskDependency, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT d.task_id, d.depends_on
+		FROM task_dependencies d
+		JOIN tasks t ON t.id = d.task_id
+		WHERE t.workflow_id = $1`, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("bog'liqliklarni olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var deps []models.TaskDependency
+	for rows.Next() {
+		var dep models.TaskDependency
+		if err := rows.Scan(&dep.TaskID, &dep.DependsOn); err != nil {
+			return nil, err
+		}
+		deps = append(deps, dep)
+	}
+
+	return deps, rows.Err()
+}
+
+func (r *WorkflowRepository) SkipDependants(ctx context.Context, taskID string) ([]models.Task, error) {
+	query := dependantsCTE + `
+		UPDATE tasks SET
+			status = 'skipped',
+			last_error = 'ota task muvaffaqiyatsiz tugadi: ' || $2,
+			updated_at = NOW()
+		WHERE id IN (SELECT task_id FROM dependants)
+			AND status = 'pending'
+			AND deleted_at IS NULL
+		RETURNING ` + taskColumns
+
+	return r.queryTasks(ctx, query, taskID, taskID)
+}
+
+func (r *WorkflowRepository) ReadyDependants(ctx context.Context, taskID string) ([]models.Task, error) {
+	query := `
+		SELECT ` + taskColumns + `
+		FROM tasks
+		WHERE id IN (SELECT task_id FROM task_dependencies WHERE depends_on = $1)
+			AND ` + readyCondition
+
+	return r.queryTasks(ctx, query, taskID)
+}
+
+func (r *WorkflowRepository) UnskipDependants(ctx context.Context, taskID string) ([]models.Task, error) {
+	// Boshqa ota task muvaffaqiyatsiz, bekor qilingan yoki (shu avlodlardan tashqarida)
+	// skipped bo'lsa task skipped qoladi; bloklangan taskning avlodlari ham skipped qoladi
+	query := dependantsCTE + `,
+		blocked AS (
+			SELECT d.task_id FROM task_dependencies d
+			JOIN dependants ON dependants.task_id = d.task_id
+			JOIN tasks p ON p.id = d.depends_on
+			WHERE p.status IN ('failed', 'cancelled')
+				OR (p.status = 'skipped' AND p.id NOT IN (SELECT task_id FROM dependants))
+			UNION
+			SELECT d.task_id FROM task_dependencies d
+			JOIN blocked ON d.depends_on = blocked.task_id
+			JOIN dependants ON dependants.task_id = d.task_id
+		)
+		UPDATE tasks SET
+			status = 'pending',
+			last_error = NULL,
+			updated_at = NOW()
+		WHERE id IN (SELECT task_id FROM dependants)
+			AND id NOT IN (SELECT task_id FROM blocked)
+			AND status = 'skipped'
+			AND deleted_at IS NULL
+		RETURNING ` + taskColumns
+
+	return r.queryTasks(ctx, query, taskID)
+}
+
+func (r *WorkflowRepository) queryTasks(ctx context.Context, query string, args ...interface{}) ([]models.Task, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("workflow tasklarini olishda xato: %w", err)
+	}
+	defer rows.Close()
+
+	var tasks []models.Task
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+
+	return tasks, rows.Err()
+}
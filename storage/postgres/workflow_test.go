@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestUnskipDependantsKeepsBlockedTasksSkipped(t *testing.T) {
+	e := newClaimEnv(t)
+	ctx := context.Background()
+
+	// retried -> child -> grandchild: ikkalasi ham qayta pending bo'ladi.
+	// broken (failed) -> other (skipped) -> blocked -> blockedChild: blocked ning
+	// boshqa ota taski hali ham skipped, shuning uchun u va avlodi skipped qoladi.
+	ids := make(map[string]string)
+	status := map[string]string{
+		"retried":      "pending",
+		"child":        "skipped",
+		"grandchild":   "skipped",
+		"broken":       "failed",
+		"other":        "skipped",
+		"blocked":      "skipped",
+		"blockedChild": "skipped",
+	}
+	for title, st := range status {
+		ids[title] = e.add(t, title, 3, 0)
+		if _, err := e.db.ExecContext(ctx, `UPDATE tasks SET status = $2 WHERE id = $1`, ids[title], st); err != nil {
+			t.Fatalf("set status: %v", err)
+		}
+	}
+	for _, dep := range [][2]string{
+		{"child", "retried"},
+		{"grandchild", "child"},
+		{"other", "broken"},
+		{"blocked", "retried"},
+		{"blocked", "other"},
+		{"blockedChild", "blocked"},
+	} {
+		if _, err := e.db.ExecContext(ctx, `
+			INSERT INTO task_dependencies (task_id, depends_on) VALUES ($1, $2)`,
+			ids[dep[0]], ids[dep[1]],
+		); err != nil {
+			t.Fatalf("add dependency: %v", err)
+		}
+	}
+
+	tasks, err := NewWorkflowRepository(e.db).UnskipDependants(ctx, ids["retried"])
+	if err != nil {
+		t.Fatalf("UnskipDependants: %v", err)
+	}
+
+	var titles []string
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	sort.Strings(titles)
+	if len(titles) != 2 || titles[0] != "child" || titles[1] != "grandchild" {
+		t.Fatalf("unskipped = %v, want [child grandchild]", titles)
+	}
+
+	for _, title := range []string{"other", "blocked", "blockedChild"} {
+		var st string
+		if err := e.db.QueryRowContext(ctx, `SELECT status FROM tasks WHERE id = $1`, ids[title]).Scan(&st); err != nil {
+			t.Fatalf("get status: %v", err)
+		}
+		if st != "skipped" {
+			t.Fatalf("%s status = %s, want skipped", title, st)
+		}
+	}
+}
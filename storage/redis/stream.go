@@ -0,0 +1,197 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamPrefix = "tasks:stream:" // Har bir nomlangan navbat uchun alohida stream
+	streamGroup  = "workers"       // Barcha workerlar bitta consumer group da
+	streamMaxLen = 100000          // Stream uzunligi taxminan shu qiymatda ushlanadi
+)
+
+// StreamMessage - navbatdagi task haqida xabar
+type StreamMessage struct {
+	ID       string // Stream yozuvi ID si (XACK uchun)
+	Queue    string
+	TaskID   string // Bo'sh bo'lishi mumkin: yozuv stream dan o'chirilgan
+	TaskType string
+}
+
+// TaskStream - Redis Streams ustidagi task navbati: har bir navbat - stream,
+// workerlar consumer group orqali o'qiydi, ishlov berilgan xabar XACK qilinadi.
+type TaskStream struct {
+	rdb    *redis.Client
+	groups sync.Map // consumer group yaratilgan streamlar
+}
+
+// NewTaskStream - yangi TaskStream yaratish
+func NewTaskStream(rdb *redis.Client) *TaskStream {
+	return &TaskStream{rdb: rdb}
+}
+
+func streamKey(queue string) string {
+	return streamPrefix + queue
+}
+
+// ensureGroup - stream va consumer group ni (bo'lmasa) yaratish. Group "0" dan
+// boshlanadi: group yaratilishidan oldin qo'shilgan xabarlar ham o'qiladi.
+func (s *TaskStream) ensureGroup(ctx context.Context, stream string) error {
+	if _, ok := s.groups.Load(stream); ok {
+		return nil
+	}
+
+	err := s.rdb.XGroupCreateMkStream(ctx, stream, streamGroup, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("consumer group yaratishda xato: %w", err)
+	}
+	s.groups.Store(stream, true)
+	return nil
+}
+
+// Add - task haqida xabarni navbat streamiga qo'shish
+func (s *TaskStream) Add(ctx context.Context, queue, taskID, taskType string) error {
+	err := s.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(queue),
+		MaxLen: streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{"task_id": taskID, "type": taskType},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("streamga yozishda xato: %w", err)
+	}
+	return nil
+}
+
+// Read - navbatdan hali hech kimga berilmagan bitta xabarni olish (kutmasdan).
+// Xabar bo'lmasa nil qaytadi.
+func (s *TaskStream) Read(ctx context.Context, queue, consumer string) (*StreamMessage, error) {
+	stream := streamKey(queue)
+	if err := s.ensureGroup(ctx, stream); err != nil {
+		return nil, err
+	}
+
+	res, err := s.rdb.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: consumer,
+		Streams:  []string{stream, ">"},
+		Count:    1,
+		Block:    -1, // Kutmaslik: bo'sh bo'lsa worker o'zi kutadi
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("streamdan o'qishda xato: %w", err)
+	}
+
+	for _, st := range res {
+		for _, msg := range st.Messages {
+			m := toMessage(queue, msg)
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+// ClaimIdle - boshqa consumer olib, minIdle dan beri tasdiqlamagan (masalan worker
+// qulagan) xabarlarni XCLAIM orqali shu consumerga o'tkazish
+func (s *TaskStream) ClaimIdle(ctx context.Context, queue, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error) {
+	stream := streamKey(queue)
+	if err := s.ensureGroup(ctx, stream); err != nil {
+		return nil, err
+	}
+
+	pending, err := s.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  streamGroup,
+		Idle:   minIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  count,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("tasdiqlanmagan xabarlarni olishda xato: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(pending))
+	for _, p := range pending {
+		ids = append(ids, p.ID)
+	}
+
+	claimed, err := s.rdb.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    streamGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle, // Oraliqda boshqa consumer olgan xabar qayta olinmaydi
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("xabarlarni o'tkazishda xato: %w", err)
+	}
+
+	msgs := make([]StreamMessage, 0, len(claimed))
+	for _, msg := range claimed {
+		msgs = append(msgs, toMessage(queue, msg))
+	}
+	return msgs, nil
+}
+
+// RemoveConsumer - consumer group dan consumerni o'chirish (XGROUP DELCONSUMER).
+// Consumerda tasdiqlanmagan xabar bo'lsa u o'chirilmaydi (DELCONSUMER ularni
+// pending ro'yxatidan yo'qotadi) va false qaytadi. Stream yoki group yo'q bo'lsa true.
+func (s *TaskStream) RemoveConsumer(ctx context.Context, queue, consumer string) (bool, error) {
+	stream := streamKey(queue)
+
+	pending, err := s.rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream:   stream,
+		Group:    streamGroup,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: consumer,
+	}).Result()
+	if isNoGroup(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("consumer xabarlarini tekshirishda xato: %w", err)
+	}
+	if len(pending) > 0 {
+		return false, nil
+	}
+
+	if err := s.rdb.XGroupDelConsumer(ctx, stream, streamGroup, consumer).Err(); err != nil && !isNoGroup(err) {
+		return false, fmt.Errorf("consumerni o'chirishda xato: %w", err)
+	}
+	return true, nil
+}
+
+// isNoGroup - stream yoki consumer group hali yaratilmagan
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
+// Ack - xabar bilan ish tugadi: u consumer group ning pending ro'yxatidan chiqariladi
+func (s *TaskStream) Ack(ctx context.Context, msg StreamMessage) error {
+	if err := s.rdb.XAck(ctx, streamKey(msg.Queue), streamGroup, msg.ID).Err(); err != nil {
+		return fmt.Errorf("xabarni tasdiqlashda xato: %w", err)
+	}
+	return nil
+}
+
+func toMessage(queue string, msg redis.XMessage) StreamMessage {
+	taskID, _ := msg.Values["task_id"].(string)
+	taskType, _ := msg.Values["type"].(string)
+	return StreamMessage{ID: msg.ID, Queue: queue, TaskID: taskID, TaskType: taskType}
+}
@@ -0,0 +1,117 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestStream(t *testing.T) (*TaskStream, *redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return NewTaskStream(rdb), rdb, mr
+}
+
+func pendingCount(t *testing.T, rdb *redis.Client, queue string) int64 {
+	t.Helper()
+
+	p, err := rdb.XPending(context.Background(), streamKey(queue), streamGroup).Result()
+	if err != nil {
+		t.Fatalf("XPENDING: %v", err)
+	}
+	return p.Count
+}
+
+func TestTaskStreamReadAck(t *testing.T) {
+	ctx := context.Background()
+	s, rdb, _ := newTestStream(t)
+
+	if err := s.Add(ctx, "default", "task-1", "email"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	msg, err := s.Read(ctx, "default", "worker-a")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if msg == nil || msg.TaskID != "task-1" || msg.TaskType != "email" || msg.Queue != "default" {
+		t.Fatalf("Read = %+v, want task-1/email in default", msg)
+	}
+
+	// O'qilgan, lekin tasdiqlanmagan xabar pending ro'yxatida qoladi
+	if n := pendingCount(t, rdb, "default"); n != 1 {
+		t.Fatalf("pending before ack = %d, want 1", n)
+	}
+	if next, err := s.Read(ctx, "default", "worker-b"); err != nil || next != nil {
+		t.Fatalf("second Read = %+v, %v; want nil, nil", next, err)
+	}
+
+	if err := s.Ack(ctx, *msg); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if n := pendingCount(t, rdb, "default"); n != 0 {
+		t.Fatalf("pending after ack = %d, want 0", n)
+	}
+}
+
+func TestTaskStreamClaimIdle(t *testing.T) {
+	ctx := context.Background()
+	s, rdb, mr := newTestStream(t)
+
+	start := time.Now()
+	mr.SetTime(start)
+
+	if err := s.Add(ctx, "default", "task-1", "email"); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if msg, err := s.Read(ctx, "default", "dead-worker"); err != nil || msg == nil {
+		t.Fatalf("Read = %+v, %v", msg, err)
+	}
+
+	// Hali minIdle o'tmagan: xabar egasida qoladi
+	msgs, err := s.ClaimIdle(ctx, "default", "worker-b", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimIdle: %v", err)
+	}
+	if len(msgs) != 0 {
+		t.Fatalf("ClaimIdle before minIdle = %d messages, want 0", len(msgs))
+	}
+
+	mr.SetTime(start.Add(2 * time.Minute))
+
+	msgs, err = s.ClaimIdle(ctx, "default", "worker-b", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("ClaimIdle: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].TaskID != "task-1" {
+		t.Fatalf("ClaimIdle = %+v, want task-1", msgs)
+	}
+
+	ext, err := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: streamKey("default"),
+		Group:  streamGroup,
+		Start:  "-",
+		End:    "+",
+		Count:  10,
+	}).Result()
+	if err != nil {
+		t.Fatalf("XPENDING: %v", err)
+	}
+	if len(ext) != 1 || ext[0].Consumer != "worker-b" {
+		t.Fatalf("pending = %+v, want one entry owned by worker-b", ext)
+	}
+
+	if err := s.Ack(ctx, msgs[0]); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if n := pendingCount(t, rdb, "default"); n != 0 {
+		t.Fatalf("pending after ack = %d, want 0", n)
+	}
+}
@@ -4,22 +4,199 @@ package storage
 import (
 	models "asynchronous/model/db"
 	"context"
+	"errors"
+	"time"
 )
 
+var (
+	// ErrNoTask - navbatda bajarishga tayyor task yo'q
+	ErrNoTask = errors.New("navbatda bajarishga tayyor task yo'q")
+	// ErrTypeLimited - task turi max_concurrency ga yetgan, task band qilinmadi
+	ErrTypeLimited = errors.New("task turi bir vaqtda bajarilish chekloviga yetgan")
+	// ErrTaskNotFound - task topilmadi (yoki o'chirilgan)
+	ErrTaskNotFound = errors.New("task topilmadi")
+	// ErrRecurringTaskNotFound - takrorlanuvchi task topilmadi
+	ErrRecurringTaskNotFound = errors.New("takrorlanuvchi task topilmadi")
+	// ErrWorkflowNotFound - workflow topilmadi
+	ErrWorkflowNotFound = errors.New("workflow topilmadi")
+	// ErrBatchNotFound - batch topilmadi
+	ErrBatchNotFound = errors.New("batch topilmadi")
+	// ErrDeadLetterNotFound - task dead-letter navbatida yo'q
+	ErrDeadLetterNotFound = errors.New("dead-letter navbatida bunday task yo'q")
+	// ErrTaskTypeLimitNotFound - task turi uchun cheklov o'rnatilmagan
+	ErrTaskTypeLimitNotFound = errors.New("task turi uchun cheklov topilmadi")
+	// ErrTaskNotOwned - task endi shu workerda bajarilmayapti (qaytarilgan, bekor qilingan yoki o'chirilgan)
+	ErrTaskNotOwned = errors.New("task endi bu workerga tegishli emas")
+	// ErrUniqueConflict - xuddi shu unique_key li task allaqachon pending yoki processing
+	ErrUniqueConflict = errors.New("xuddi shu unique_key li task allaqachon navbatda")
+	// ErrWebhookNotFound - webhook topilmadi
+	ErrWebhookNotFound = errors.New("webhook topilmadi")
+)
+
+// ClaimOptions - navbatdan task olish parametrlari
+type ClaimOptions struct {
+	// PriorityAging - task shu vaqt kutgan sari uning priority si bittaga oshadi (5 dan oshmaydi).
+	// 0 bo'lsa tasklar faqat o'z priority si bo'yicha tanlanadi.
+	PriorityAging time.Duration
+	// Queues - task olinadigan navbatlar, ustuvorlik tartibida. Bo'sh bo'lsa barcha navbatlar.
+	Queues []string
+	// ExcludeTypes - shu turdagi tasklar olinmaydi (cheklovga yetgan turlar)
+	ExcludeTypes []string
+	// WorkerID - taskni band qilayotgan worker (claimed_by ga yoziladi)
+	WorkerID string
+	// Lease - heartbeat kelmasa task shu vaqtdan keyin reaper tomonidan qaytariladi
+	Lease time.Duration
+}
+
 type IStorage interface {
 	Task() ITaskStorage
 	User() IUserStorage
 	TaskResult() ITaskResultStorage
+	RecurringTask() IRecurringTaskStorage
+	DeadLetter() IDeadLetterStorage
+	TaskAttempt() ITaskAttemptStorage
+	Workflow() IWorkflowStorage
+	Batch() IBatchStorage
+	TaskTypeLimit() ITaskTypeLimitStorage
+	Queue() IQueueStorage
+	TaskEvent() ITaskEventStorage
+	Worker() IWorkerStorage
+	Webhook() IWebhookStorage
 	Close()
 }
 
 type ITaskStorage interface {
 	CreateTask(ctx context.Context, task models.Task) (string, error)
+	// CreateTaskOnce - idempotency_key yoki unique_key bo'yicha takrorlanmaydigan task yaratadi.
+	// Mos task allaqachon bo'lsa u created=false bilan qaytadi.
+	CreateTaskOnce(ctx context.Context, task models.Task, retention time.Duration) (models.Task, bool, error)
 	GetTask(ctx context.Context, id string) (models.Task, error)
+	// UpdateTask - taskni yangilash; pending ga qaytgan task mavjud unique_key bilan
+	// to'qnashsa ErrUniqueConflict
 	UpdateTask(ctx context.Context, task models.Task) error
+	// UpdateClaimedTask - workerdan kelgan yozuv: faqat task hali workerID da "processing"
+	// bo'lsa yoziladi, aks holda ErrTaskNotOwned
+	UpdateClaimedTask(ctx context.Context, task models.Task, workerID string) error
 	DeleteTask(ctx context.Context, id string) error
 	ListTasks(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]models.Task, error)
 	UpdateTaskStatus(ctx context.Context, taskID string, status string) error
+	// ClaimTask - eng muhim tayyor taskni band qilish. task_type_limits dagi max_concurrency
+	// barcha instancelar bo'yicha shu yerda tekshiriladi.
+	ClaimTask(ctx context.Context, opts ClaimOptions) (models.Task, error)
+	// ClaimTaskByID - aynan shu taskni band qilish; tayyor bo'lmasa ErrNoTask,
+	// turi max_concurrency ga yetgan bo'lsa ErrTypeLimited
+	ClaimTaskByID(ctx context.Context, taskID string, opts ClaimOptions) (models.Task, error)
+	// TouchStaleReady - uzoq vaqt olinmagan tayyor tasklar (updated_at yangilanadi)
+	TouchStaleReady(ctx context.Context, queues []string, older time.Duration, limit int) ([]models.Task, error)
+	ListDelayedTasks(ctx context.Context, until time.Time) ([]models.Task, error)
+	// ReleaseTask - workerID band qilgan "processing" taskni "pending" ga qaytaradi;
+	// task endi shu workerda bo'lmasa ErrTaskNotOwned
+	ReleaseTask(ctx context.Context, taskID, workerID string) error
+	ExtendLease(ctx context.Context, taskID, workerID string, lease time.Duration) (bool, error)
+	// ReapExpiredLeases - lease muddati o'tgan tasklarni qaytarish; qayta urinish qolganlari
+	// nextRetry(task) vaqtida (task.Retries oshirilgan) bajariladi
+	ReapExpiredLeases(ctx context.Context, nextRetry func(task models.Task) time.Time) ([]models.Task, error)
+	// CancelTask - pending yoki processing taskni "cancelled" qiladi.
+	// Task yo'q yoki allaqachon yakunlangan bo'lsa ErrTaskNotFound qaytaradi.
+	CancelTask(ctx context.Context, taskID, cancelledBy, reason string) (models.Task, error)
+	RecordTaskError(ctx context.Context, taskID string, attempt int, errMsg string) error
+}
+
+// DeadLetterFilter - dead-letter tasklarini tanlash. IDs bo'sh bo'lsa
+// qolgan shartlarga mos barcha tasklar tanlanadi.
+type DeadLetterFilter struct {
+	IDs  []string
+	Type string
+}
+
+type IDeadLetterStorage interface {
+	ListDeadLetters(ctx context.Context, filter DeadLetterFilter, limit, offset int) ([]models.DeadLetter, error)
+	GetDeadLetter(ctx context.Context, taskID string) (models.DeadLetter, error)
+	// Requeue - tanlangan tasklarni retries=0 bilan "pending" ga qaytaradi va ularni qaytaradi.
+	// Biror task mavjud unique_key bilan to'qnashsa hech biri qaytarilmaydi (ErrUniqueConflict).
+	Requeue(ctx context.Context, filter DeadLetterFilter) ([]models.Task, error)
+	// Purge - tanlangan tasklarni butunlay o'chiradi, o'chirilganlar sonini qaytaradi
+	Purge(ctx context.Context, filter DeadLetterFilter) (int64, error)
+}
+
+type IWorkflowStorage interface {
+	// CreateWorkflow - workflow, uning tasklari va bog'liqliklarini bitta tranzaksiyada yaratadi.
+	// Task ID lari oldindan to'ldirilgan bo'lishi kerak.
+	CreateWorkflow(ctx context.Context, wf models.Workflow, tasks []models.Task, deps []models.TaskDependency) error
+	GetWorkflow(ctx context.Context, id string) (models.Workflow, error)
+	ListWorkflowTasks(ctx context.Context, workflowID string) ([]models.Task, error)
+	ListDependencies(ctx context.Context, workflowID string) ([]models.TaskDependency, error)
+	// SkipDependants - taskka (bevosita yoki bilvosita) bog'liq pending tasklarni "skipped" qiladi
+	SkipDependants(ctx context.Context, taskID string) ([]models.Task, error)
+	// ReadyDependants - task tugagach bajarishga tayyor bo'lgan bevosita bog'liq pending tasklar
+	ReadyDependants(ctx context.Context, taskID string) ([]models.Task, error)
+	// UnskipDependants - task qayta navbatga qo'yilganda skipped avlodlarini "pending" ga qaytaradi
+	UnskipDependants(ctx context.Context, taskID string) ([]models.Task, error)
+}
+
+type IBatchStorage interface {
+	// CreateBatch - batch va uning barcha tasklarini bitta tranzaksiyada yaratadi.
+	// Task ID lari oldindan to'ldirilgan bo'lishi kerak.
+	CreateBatch(ctx context.Context, batch models.Batch, tasks []models.Task) error
+	GetBatch(ctx context.Context, id string) (models.Batch, error)
+	CountByStatus(ctx context.Context, batchID string) (map[string]int, error)
+	// MarkCompleted - batchdagi barcha tasklar tugagan bo'lsa completed_at ni qo'yadi.
+	// Faqat birinchi muvaffaqiyatli chaqiruv true qaytaradi: hook bir marta ishga tushadi.
+	// hook bo'lsa uning webhook yuborilishi shu tranzaksiyada yaratiladi.
+	MarkCompleted(ctx context.Context, batchID string, completedAt time.Time, hook *models.WebhookDelivery) (models.Batch, bool, error)
+}
+
+type ITaskTypeLimitStorage interface {
+	ListLimits(ctx context.Context) ([]models.TaskTypeLimit, error)
+	// UpsertLimit - cheklovni yaratish yoki almashtirish; updated_at to'ldirilgan holda qaytadi
+	UpsertLimit(ctx context.Context, limit models.TaskTypeLimit) (models.TaskTypeLimit, error)
+	DeleteLimit(ctx context.Context, taskType string) error
+	// RunningCounts - cheklangan turlar bo'yicha barcha instancelarda bajarilayotgan tasklar soni
+	RunningCounts(ctx context.Context) (map[string]int, error)
+}
+
+type IQueueStorage interface {
+	ListPaused(ctx context.Context) ([]models.PausedQueue, error)
+	// Pause - navbatni to'xtatilganlar ro'yxatiga qo'shish (allaqachon bo'lsa o'zgarmaydi)
+	Pause(ctx context.Context, queue, pausedBy string) error
+	// Resume - navbat to'xtatilgan bo'lsa true qaytaradi
+	Resume(ctx context.Context, queue string) (bool, error)
+	// Depths - har bir navbatdagi kutayotgan va bajarilayotgan tasklar soni
+	Depths(ctx context.Context) ([]models.QueueDepth, error)
+}
+
+// IWorkerStorage - worker jarayonlari holatlari (barcha instancelarni ko'rsatish uchun)
+type IWorkerStorage interface {
+	ReportState(ctx context.Context, state models.PoolState) error
+	// ListStates - since dan keyin holat yuborgan instancelar
+	ListStates(ctx context.Context, since time.Time) ([]models.PoolState, error)
+	RemoveState(ctx context.Context, instanceID string) error
+	// SendCommand - buyruqni yozish va worker jarayonlariga NOTIFY yuborish; ID to'ldirilgan holda qaytadi
+	SendCommand(ctx context.Context, cmd models.WorkerCommand) (models.WorkerCommand, error)
+	// ListCommands - afterID dan keyingi barcha jarayonlarga yoki instanceID ga yuborilgan buyruqlar
+	ListCommands(ctx context.Context, instanceID string, afterID int64) ([]models.WorkerCommand, error)
+	// LastCommandID - oxirgi buyruq ID si (jarayon ishga tushganda eski buyruqlarni o'tkazib yuborish uchun)
+	LastCommandID(ctx context.Context) (int64, error)
+}
+
+type ITaskAttemptStorage interface {
+	// StartAttempt - urinishni boshlash; Attempt tartib raqami to'ldirilgan holda qaytadi
+	StartAttempt(ctx context.Context, attempt models.TaskAttempt) (models.TaskAttempt, error)
+	FinishAttempt(ctx context.Context, attempt models.TaskAttempt) error
+	AbandonAttempts(ctx context.Context, taskIDs []string) error
+	ListAttempts(ctx context.Context, taskID string) ([]models.TaskAttempt, error)
+}
+
+type IRecurringTaskStorage interface {
+	CreateRecurringTask(ctx context.Context, rt models.RecurringTask) (string, error)
+	GetRecurringTask(ctx context.Context, id string) (models.RecurringTask, error)
+	ListRecurringTasks(ctx context.Context, filters map[string]interface{}, limit, offset int) ([]models.RecurringTask, error)
+	SetPaused(ctx context.Context, id string, paused bool, nextRunAt time.Time) error
+	// FireDue - vaqti kelgan ta'riflar uchun bitta tranzaksiyada task yaratadi va
+	// next_run_at ni next orqali hisoblangan vaqtga suradi. Yaratilgan tasklarni qaytaradi.
+	// next xato qaytargan ta'rif task yaratilmasdan to'xtatiladi (paused); xatoni next
+	// chaqiruvchisi qayd qiladi.
+	FireDue(ctx context.Context, now time.Time, next func(models.RecurringTask) (time.Time, error)) ([]models.Task, error)
 }
 
 type ITaskResultStorage interface {
@@ -39,3 +216,27 @@ type IUserStorage interface {
 	ListUsers(ctx context.Context, limit, offset int) ([]models.User, error)
 	ListUsersByRole(ctx context.Context, role models.Role, limit, offset int) ([]models.User, error)
 }
+
+// ITaskEventStorage - task holati o'zgarishlarini barcha API instancelarga yetkazish
+type ITaskEventStorage interface {
+	Publish(ctx context.Context, event models.TaskEvent) error
+}
+
+type IWebhookStorage interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhook(ctx context.Context, id string) (models.Webhook, error)
+	// ListWebhooks - userID bo'sh bo'lsa barcha webhooklar
+	ListWebhooks(ctx context.Context, userID string) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+	// EnqueueDeliveries - userIDs dagi foydalanuvchilarning event ga obuna bo'lgan
+	// webhooklari uchun yuborish yozuvlarini yaratadi. Yaratilganlar sonini qaytaradi.
+	EnqueueDeliveries(ctx context.Context, userIDs []string, taskID, event string, payload []byte) (int64, error)
+	// ClaimDeliveries - vaqti kelgan yuborishlarni olish. Ularning next_attempt_at i
+	// lease ga suriladi: shu vaqt ichida boshqa instance ularni olmaydi.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// FinishAttempt - urinish natijasini (status, attempts, next_attempt_at va h.k.) yozish
+	FinishAttempt(ctx context.Context, delivery models.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID string, limit, offset int) ([]models.WebhookDelivery, error)
+	// GetBatchDelivery - batch on_complete webhookining oxirgi yuborilishi; bo'lmasa nil
+	GetBatchDelivery(ctx context.Context, batchID string) (*models.WebhookDelivery, error)
+}